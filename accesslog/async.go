@@ -0,0 +1,241 @@
+package accesslog
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
+	"sync"
+)
+
+// DropPolicy 队列满了之后的处理策略
+type DropPolicy uint8
+
+const (
+	// DropNewest 丢弃当前这条日志，默认策略
+	DropNewest DropPolicy = iota
+	// DropOldest 丢弃队列里面最早的日志
+	DropOldest
+	// Block 阻塞直到队列有空位，不会丢日志，但是会拖慢业务
+	Block
+)
+
+type AsyncOption func(a *asyncCore)
+
+// WithDropPolicy 设置队列满了之后的处理策略
+func WithDropPolicy(policy DropPolicy) AsyncOption {
+	return func(a *asyncCore) {
+		a.policy = policy
+	}
+}
+
+// WithDroppedCounter 上报丢弃的日志条数到 prometheus
+// 同样的 opts 已经注册过的时候，复用已经注册的 Counter，多个 AsyncLogger 累加到一起
+func WithDroppedCounter(opts prometheus.CounterOpts) AsyncOption {
+	return func(a *asyncCore) {
+		var counter prometheus.Counter = prometheus.NewCounter(opts)
+		err := prometheus.Register(counter)
+		if err != nil {
+			are, ok := err.(prometheus.AlreadyRegisteredError)
+			if !ok {
+				panic(err)
+			}
+			counter = are.ExistingCollector.(prometheus.Counter)
+		}
+		a.counter = counter
+	}
+}
+
+// AsyncLogger 异步写日志
+// 日志先放进有界队列，由后台 goroutine 写入真正的 Logger，避免阻塞业务 goroutine
+// 注意 Field 里面引用的对象，在写入之前不要修改
+type AsyncLogger struct {
+	l    Logger
+	core *asyncCore
+}
+
+// NewAsyncLogger 创建异步 Logger，size 是队列的容量
+// 使用完毕之后要调用 Close，保证队列里面的日志都写进去了
+func NewAsyncLogger(l Logger, size int, opts ...AsyncOption) *AsyncLogger {
+	core := newAsyncCore(size)
+	for _, opt := range opts {
+		opt(core)
+	}
+	go core.run()
+	return &AsyncLogger{
+		l:    l,
+		core: core,
+	}
+}
+
+func (a *AsyncLogger) Debug(msg string, args ...Field) {
+	a.core.enqueue(a.l, DebugLevel, msg, args)
+}
+
+func (a *AsyncLogger) Info(msg string, args ...Field) {
+	a.core.enqueue(a.l, InfoLevel, msg, args)
+}
+
+func (a *AsyncLogger) Warn(msg string, args ...Field) {
+	a.core.enqueue(a.l, WarnLevel, msg, args)
+}
+
+func (a *AsyncLogger) Error(msg string, args ...Field) {
+	a.core.enqueue(a.l, ErrorLevel, msg, args)
+}
+
+func (a *AsyncLogger) WithContext(ctx context.Context) Logger {
+	return &AsyncLogger{
+		l:    a.l.WithContext(ctx),
+		core: a.core,
+	}
+}
+
+func (a *AsyncLogger) With(args ...Field) Logger {
+	return &AsyncLogger{
+		l:    a.l.With(args...),
+		core: a.core,
+	}
+}
+
+func (a *AsyncLogger) Named(name string) Logger {
+	return &AsyncLogger{
+		l:    a.l.Named(name),
+		core: a.core,
+	}
+}
+
+// Dropped 累计丢弃的日志条数
+func (a *AsyncLogger) Dropped() int64 {
+	return a.core.dropped.Load()
+}
+
+// Flush 等待在此之前放进队列的日志都写完
+func (a *AsyncLogger) Flush() error {
+	a.core.lock.RLock()
+	if a.core.closed {
+		a.core.lock.RUnlock()
+		return nil
+	}
+	done := make(chan struct{})
+	a.core.flushes <- done
+	a.core.lock.RUnlock()
+	<-done
+	return nil
+}
+
+// Close 不再接收新的日志，并且等待队列里面的日志写完
+// 子 Logger 共享同一个队列，关闭任意一个都会全部关闭
+func (a *AsyncLogger) Close() error {
+	a.core.lock.Lock()
+	if !a.core.closed {
+		a.core.closed = true
+		close(a.core.queue)
+	}
+	a.core.lock.Unlock()
+	<-a.core.done
+	return nil
+}
+
+type asyncEntry struct {
+	l     Logger
+	level Level
+	msg   string
+	args  []Field
+}
+
+type asyncCore struct {
+	queue chan asyncEntry
+	// Flush 的请求单独传递，不放进 queue，DropOldest 丢弃的只会是日志
+	flushes chan chan struct{}
+	policy  DropPolicy
+	dropped *atomic.Int64
+	counter prometheus.Counter
+
+	// 保护 closed 和 queue 的关闭，避免往已经关闭的 channel 里面写
+	lock   sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func newAsyncCore(size int) *asyncCore {
+	return &asyncCore{
+		queue:   make(chan asyncEntry, size),
+		flushes: make(chan chan struct{}),
+		dropped: atomic.NewInt64(0),
+		done:    make(chan struct{}),
+	}
+}
+
+func (a *asyncCore) enqueue(l Logger, level Level, msg string, args []Field) {
+	// 复制一份，避免调用方复用切片
+	fields := make([]Field, len(args))
+	copy(fields, args)
+	entry := asyncEntry{l: l, level: level, msg: msg, args: fields}
+
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.closed {
+		a.drop()
+		return
+	}
+	switch a.policy {
+	case Block:
+		a.queue <- entry
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- entry:
+				return
+			default:
+			}
+			// 队列满了，腾出一个位置
+			select {
+			case <-a.queue:
+				a.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case a.queue <- entry:
+		default:
+			a.drop()
+		}
+	}
+}
+
+func (a *asyncCore) drop() {
+	a.dropped.Inc()
+	if a.counter != nil {
+		a.counter.Inc()
+	}
+}
+
+func (a *asyncCore) run() {
+	defer close(a.done)
+	for {
+		select {
+		case entry, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			logAt(entry.l, entry.level, entry.msg, entry.args)
+		case flushed := <-a.flushes:
+			// Flush 之前放进队列的日志都在队列里面，写完这么多条就可以返回了
+			// DropOldest 同时丢弃的话，多写几条新的日志，不影响结果
+			a.drain(len(a.queue))
+			close(flushed)
+		}
+	}
+}
+
+// drain 写 n 条日志，队列关闭了就提前返回
+func (a *asyncCore) drain(n int) {
+	for i := 0; i < n; i++ {
+		entry, ok := <-a.queue
+		if !ok {
+			return
+		}
+		logAt(entry.l, entry.level, entry.msg, entry.args)
+	}
+}
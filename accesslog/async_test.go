@@ -0,0 +1,113 @@
+package accesslog
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"sync"
+	"testing"
+)
+
+func TestAsyncLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewAsyncLogger(NewZapLogger(zap.New(core)), 100)
+	child := l.With(String("biz", "test"))
+	for i := 0; i < 10; i++ {
+		child.Info("hello", Int64("idx", int64(i)))
+	}
+	require.NoError(t, l.Flush())
+	entries := logs.TakeAll()
+	require.Len(t, entries, 10)
+	for i, e := range entries {
+		assert.Equal(t, map[string]any{"biz": "test", "idx": int64(i)}, e.ContextMap())
+	}
+
+	child.Error("before close")
+	require.NoError(t, l.Close())
+	assert.Equal(t, 1, logs.Len())
+	// 关闭之后的日志直接丢弃
+	child.Error("after close")
+	assert.Equal(t, int64(1), l.Dropped())
+	require.NoError(t, l.Close())
+	require.NoError(t, l.Flush())
+}
+
+func TestAsyncLogger_DropPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy DropPolicy
+		want   []int64
+	}{
+		{
+			name:   "丢弃最新的",
+			policy: DropNewest,
+			want:   []int64{0, 1},
+		},
+		{
+			name:   "丢弃最早的",
+			policy: DropOldest,
+			want:   []int64{3, 4},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			l := &AsyncLogger{
+				l:    NewZapLogger(zap.New(core)),
+				core: newAsyncCore(2),
+			}
+			l.core.policy = tc.policy
+			// 后台 goroutine 还没有启动，队列只能放两条
+			for i := 0; i < 5; i++ {
+				l.Info("hello", Int64("idx", int64(i)))
+			}
+			go l.core.run()
+			require.NoError(t, l.Close())
+
+			assert.Equal(t, int64(3), l.Dropped())
+			var idx []int64
+			for _, e := range logs.All() {
+				idx = append(idx, e.ContextMap()["idx"].(int64))
+			}
+			assert.Equal(t, tc.want, idx)
+		})
+	}
+}
+
+// TestAsyncLogger_FlushWhileDropping 队列满了一直在丢弃的时候，Flush 也能返回
+// 并且 Flush 之前写的日志都已经写完了
+func TestAsyncLogger_FlushWhileDropping(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewAsyncLogger(NewZapLogger(zap.New(core)), 2, WithDropPolicy(DropOldest))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				l.Info("noise")
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		l.Warn("flush", Int64("idx", int64(i)))
+		require.NoError(t, l.Flush())
+	}
+	wg.Wait()
+	require.NoError(t, l.Close())
+	assert.Equal(t, int64(4100), int64(logs.Len())+l.Dropped())
+}
+
+// TestWithDroppedCounter 重复创建不会 panic，复用已经注册的 Counter
+func TestWithDroppedCounter(t *testing.T) {
+	opts := prometheus.CounterOpts{Name: "async_logger_test_dropped"}
+	first, second := newAsyncCore(1), newAsyncCore(1)
+	WithDroppedCounter(opts)(first)
+	assert.NotPanics(t, func() {
+		WithDroppedCounter(opts)(second)
+	})
+	assert.Same(t, first.counter, second.counter)
+}
@@ -0,0 +1,27 @@
+package accesslog
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextFields 提取 ctx 中需要打印的字段
+// 目前是 opentelemetry 的 trace_id 和 span_id，以及 request_id
+// 自定义的 Logger 实现也可以复用
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	var res []Field
+	if id := requestid.FromContext(ctx); id != "" {
+		res = append(res, String("request_id", id))
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return res
+	}
+	return append(res,
+		String("trace_id", sc.TraceID().String()),
+		String("span_id", sc.SpanID().String()))
+}
@@ -1,27 +1,40 @@
 package accesslog
 
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
 func String(key, val string) Field {
 	return Field{
-		Key:   key,
-		Value: val,
+		Key:    key,
+		Type:   StringType,
+		String: val,
 	}
 }
 
 func Int32(key string, val int32) Field {
 	return Field{
-		Key:   key,
-		Value: val,
+		Key:     key,
+		Type:    Int32Type,
+		Integer: int64(val),
 	}
 }
 
 func Bool(key string, val bool) Field {
-	return Field{Key: key, Value: val}
+	var i int64
+	if val {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: i}
 }
 
 func Int64(key string, val int64) Field {
 	return Field{
-		Key:   key,
-		Value: val,
+		Key:     key,
+		Type:    Int64Type,
+		Integer: val,
 	}
 }
 
@@ -29,6 +42,65 @@ func Error(err error) Field {
 	return Field{
 		Key:   "error",
 		Value: err,
+		Type:  ErrorType,
+	}
+}
+
+func Duration(key string, val time.Duration) Field {
+	return Field{
+		Key:     key,
+		Type:    DurationType,
+		Integer: int64(val),
+	}
+}
+
+func Time(key string, val time.Time) Field {
+	return Field{
+		Key:   key,
+		Value: val,
+		Type:  TimeType,
+	}
+}
+
+func Float64(key string, val float64) Field {
+	return Field{
+		Key:     key,
+		Type:    Float64Type,
+		Integer: int64(math.Float64bits(val)),
+	}
+}
+
+func Uint64(key string, val uint64) Field {
+	return Field{
+		Key:     key,
+		Type:    Uint64Type,
+		Integer: int64(val),
+	}
+}
+
+func Strings(key string, val []string) Field {
+	return Field{
+		Key:   key,
+		Value: val,
+		Type:  StringsType,
+	}
+}
+
+// Stringer 输出的时候才调用 String 方法
+func Stringer(key string, val fmt.Stringer) Field {
+	return Field{
+		Key:   key,
+		Value: val,
+		Type:  StringerType,
+	}
+}
+
+// Object 输出嵌套的对象，字段由 val 决定
+func Object(key string, val ObjectMarshaler) Field {
+	return Field{
+		Key:   key,
+		Value: val,
+		Type:  ObjectType,
 	}
 }
 
@@ -0,0 +1,228 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/atomic"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Level 日志级别，取值和 zapcore.Level 保持一致
+type Level int8
+
+const (
+	DebugLevel Level = iota - 1
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var ErrLoggerNotFound = errors.New("logger 不存在")
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	default:
+		return fmt.Sprintf("Level(%d)", l)
+	}
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	lvl, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lvl
+	return nil
+}
+
+// ParseLevel 解析日志级别，不区分大小写
+// 空字符串会返回错误，避免配置写错的时候悄悄变成 info
+func ParseLevel(text string) (Level, error) {
+	switch strings.ToLower(text) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("未知的日志级别 %s", text)
+	}
+}
+
+// Log 按照 level 调用对应的方法
+func Log(l Logger, level Level, msg string, args ...Field) {
+	logAt(l, level, msg, args)
+}
+
+// logAt 按照级别分发日志
+func logAt(l Logger, level Level, msg string, args []Field) {
+	switch {
+	case level <= DebugLevel:
+		l.Debug(msg, args...)
+	case level == InfoLevel:
+		l.Info(msg, args...)
+	case level == WarnLevel:
+		l.Warn(msg, args...)
+	default:
+		l.Error(msg, args...)
+	}
+}
+
+// LevelSetter 被包装的 Logger 自己也有级别的时候实现这个接口
+// 例如 NewZapLoggerWithLevel 创建的 Logger，否则被包装的 Logger 会把调低级别之后的日志过滤掉
+type LevelSetter interface {
+	SetLevel(level Level)
+}
+
+// LevelLogger 可以在运行期间调整日志级别
+// 低于当前级别的日志会被丢弃，子 Logger 共享同一个级别
+// l 实现了 LevelSetter 的时候，会同步修改 l 的级别
+type LevelLogger struct {
+	l      Logger
+	level  *atomic.Int32
+	setter LevelSetter
+}
+
+func NewLevelLogger(l Logger, level Level) *LevelLogger {
+	setter, _ := l.(LevelSetter)
+	res := &LevelLogger{
+		l:      l,
+		level:  atomic.NewInt32(int32(level)),
+		setter: setter,
+	}
+	res.SetLevel(level)
+	return res
+}
+
+// SetLevel 修改日志级别，并发安全
+func (d *LevelLogger) SetLevel(level Level) {
+	d.level.Store(int32(level))
+	if d.setter != nil {
+		d.setter.SetLevel(level)
+	}
+}
+
+func (d *LevelLogger) Level() Level {
+	return Level(d.level.Load())
+}
+
+func (d *LevelLogger) Enabled(level Level) bool {
+	return level >= d.Level()
+}
+
+func (d *LevelLogger) Debug(msg string, args ...Field) {
+	if d.Enabled(DebugLevel) {
+		d.l.Debug(msg, args...)
+	}
+}
+
+func (d *LevelLogger) Info(msg string, args ...Field) {
+	if d.Enabled(InfoLevel) {
+		d.l.Info(msg, args...)
+	}
+}
+
+func (d *LevelLogger) Warn(msg string, args ...Field) {
+	if d.Enabled(WarnLevel) {
+		d.l.Warn(msg, args...)
+	}
+}
+
+func (d *LevelLogger) Error(msg string, args ...Field) {
+	if d.Enabled(ErrorLevel) {
+		d.l.Error(msg, args...)
+	}
+}
+
+func (d *LevelLogger) WithContext(ctx context.Context) Logger {
+	return &LevelLogger{
+		l:      d.l.WithContext(ctx),
+		level:  d.level,
+		setter: d.setter,
+	}
+}
+
+func (d *LevelLogger) With(args ...Field) Logger {
+	return &LevelLogger{
+		l:      d.l.With(args...),
+		level:  d.level,
+		setter: d.setter,
+	}
+}
+
+func (d *LevelLogger) Named(name string) Logger {
+	return &LevelLogger{
+		l:      d.l.Named(name),
+		level:  d.level,
+		setter: d.setter,
+	}
+}
+
+// LevelRegistry 按照名称管理 LevelLogger
+// 便于通过管理接口调整某个组件的日志级别
+type LevelRegistry struct {
+	lock    sync.RWMutex
+	loggers map[string]*LevelLogger
+}
+
+func NewLevelRegistry() *LevelRegistry {
+	return &LevelRegistry{
+		loggers: make(map[string]*LevelLogger),
+	}
+}
+
+// Register 包装 l 并注册，重复注册会覆盖
+func (r *LevelRegistry) Register(name string, l Logger, level Level) *LevelLogger {
+	res := NewLevelLogger(l, level)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.loggers[name] = res
+	return res
+}
+
+func (r *LevelRegistry) Get(name string) (*LevelLogger, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	l, ok := r.loggers[name]
+	return l, ok
+}
+
+// SetLevel 修改指定 logger 的级别
+func (r *LevelRegistry) SetLevel(name string, level Level) error {
+	l, ok := r.Get(name)
+	if !ok {
+		return ErrLoggerNotFound
+	}
+	l.SetLevel(level)
+	return nil
+}
+
+// Names 所有注册的 logger 名称，按照字母序
+func (r *LevelRegistry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	res := make([]string, 0, len(r.loggers))
+	for name := range r.loggers {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
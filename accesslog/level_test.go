@@ -0,0 +1,79 @@
+package accesslog
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestLevelLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	registry := NewLevelRegistry()
+	l := registry.Register("grpc", NewZapLogger(zap.New(core)), InfoLevel)
+	child := l.With(String("biz", "test"))
+
+	child.Debug("续约心跳")
+	assert.Equal(t, 0, logs.Len())
+
+	// 子 Logger 共享级别
+	require.NoError(t, registry.SetLevel("grpc", DebugLevel))
+	child.Debug("续约心跳")
+	assert.Equal(t, 1, logs.Len())
+
+	require.NoError(t, registry.SetLevel("grpc", ErrorLevel))
+	child.Warn("warn")
+	child.Error("error")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "error", entries[1].Message)
+
+	assert.Equal(t, ErrLoggerNotFound, registry.SetLevel("unknown", DebugLevel))
+	assert.Equal(t, []string{"grpc"}, registry.Names())
+}
+
+// TestLevelLogger_ZapLevel core 是 Info 的时候，SetLevel 也能打开 Debug
+func TestLevelLogger_ZapLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	core, logs := observer.New(level)
+	registry := NewLevelRegistry()
+	l := registry.Register("grpc", NewZapLoggerWithLevel(zap.New(core), level), InfoLevel)
+	child := l.With(String("biz", "test"))
+
+	child.Debug("续约心跳")
+	assert.Equal(t, 0, logs.Len())
+
+	require.NoError(t, registry.SetLevel("grpc", DebugLevel))
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+	child.Debug("续约心跳")
+	assert.Equal(t, 1, logs.Len())
+
+	child.(*LevelLogger).SetLevel(WarnLevel)
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+	l.Info("info")
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		text    string
+		want    Level
+		wantErr bool
+	}{
+		{text: "DEBUG", want: DebugLevel},
+		{text: "info", want: InfoLevel},
+		{text: "warning", want: WarnLevel},
+		{text: "error", want: ErrorLevel},
+		{text: "fatal", want: InfoLevel, wantErr: true},
+		{text: "", want: InfoLevel, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			lvl, err := ParseLevel(tc.text)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, lvl)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package leveladmin
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ErrLoggerNotFound 没有注册这个名称的 logger
+var ErrLoggerNotFound = ginx.RegisterError(ginx.ReservedCodeMin+http.StatusNotFound, "logger 不存在",
+	http.StatusNotFound, accesslog.WarnLevel)
+
+// Handler 通过 http 接口查看和修改日志级别
+// 不需要重新部署就能打开 Debug 日志
+type Handler struct {
+	registry *accesslog.LevelRegistry
+}
+
+func NewHandler(registry *accesslog.LevelRegistry) *Handler {
+	return &Handler{
+		registry: registry,
+	}
+}
+
+// RegisterRoutes 注册路由
+// 建议挂在管理端口上，不要暴露给外部
+func (h *Handler) RegisterRoutes(server *gin.RouterGroup) {
+	server.GET("/levels", ginx.Wrap(h.List))
+	server.GET("/levels/:name", ginx.Wrap(h.Get))
+	server.PUT("/levels/:name", ginx.WrapBodyV1[SetLevelRequest](h.Set))
+}
+
+// List 所有 logger 的级别
+func (h *Handler) List(ctx *gin.Context) (ginx.Result, error) {
+	names := h.registry.Names()
+	res := make([]LevelVO, 0, len(names))
+	for _, name := range names {
+		l, ok := h.registry.Get(name)
+		if !ok {
+			continue
+		}
+		res = append(res, LevelVO{Name: name, Level: l.Level().String()})
+	}
+	return ginx.Result{
+		Msg:  "OK",
+		Data: res,
+	}, nil
+}
+
+// Get 指定 logger 的级别
+func (h *Handler) Get(ctx *gin.Context) (ginx.Result, error) {
+	name := ctx.Param("name")
+	l, ok := h.registry.Get(name)
+	if !ok {
+		return ginx.Result{}, ErrLoggerNotFound
+	}
+	return ginx.Result{
+		Msg:  "OK",
+		Data: LevelVO{Name: name, Level: l.Level().String()},
+	}, nil
+}
+
+// Set 修改指定 logger 的级别
+func (h *Handler) Set(ctx *gin.Context, req SetLevelRequest) (ginx.Result, error) {
+	level, err := accesslog.ParseLevel(req.Level)
+	if err != nil {
+		return ginx.Result{}, ginx.ErrBadRequest.Wrap(err)
+	}
+	name := ctx.Param("name")
+	err = h.registry.SetLevel(name, level)
+	if err != nil {
+		return ginx.Result{}, ErrLoggerNotFound.Wrap(err)
+	}
+	return ginx.Result{
+		Msg:  "OK",
+		Data: LevelVO{Name: name, Level: level.String()},
+	}, nil
+}
+
+type SetLevelRequest struct {
+	// debug, info, warn, error
+	Level string `json:"level" binding:"required"`
+}
+
+type LevelVO struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
@@ -0,0 +1,126 @@
+package leveladmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	ginx.L = accesslog.NewNopLogger()
+	ginx.InitCounter(prometheus.CounterOpts{Name: "leveladmin_test_code"})
+	m.Run()
+}
+
+func TestHandler(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+
+		wantCode   int
+		wantResult ginx.Result
+		wantLevel  accesslog.Level
+	}{
+		{
+			name:     "查询所有",
+			method:   http.MethodGet,
+			path:     "/levels",
+			wantCode: http.StatusOK,
+			wantResult: ginx.Result{Msg: "OK", Data: []any{
+				map[string]any{"name": "grpc", "level": "info"},
+			}},
+			wantLevel: accesslog.InfoLevel,
+		},
+		{
+			name:       "查询单个",
+			method:     http.MethodGet,
+			path:       "/levels/grpc",
+			wantCode:   http.StatusOK,
+			wantResult: ginx.Result{Msg: "OK", Data: map[string]any{"name": "grpc", "level": "info"}},
+			wantLevel:  accesslog.InfoLevel,
+		},
+		{
+			name:       "查询不存在的 logger",
+			method:     http.MethodGet,
+			path:       "/levels/unknown",
+			wantCode:   http.StatusNotFound,
+			wantResult: ginx.Result{Code: ErrLoggerNotFound.Code, Msg: ErrLoggerNotFound.Msg},
+			wantLevel:  accesslog.InfoLevel,
+		},
+		{
+			name:       "修改级别",
+			method:     http.MethodPut,
+			path:       "/levels/grpc",
+			body:       `{"level":"DEBUG"}`,
+			wantCode:   http.StatusOK,
+			wantResult: ginx.Result{Msg: "OK", Data: map[string]any{"name": "grpc", "level": "debug"}},
+			wantLevel:  accesslog.DebugLevel,
+		},
+		{
+			name:       "不合法的级别",
+			method:     http.MethodPut,
+			path:       "/levels/grpc",
+			body:       `{"level":"verbose"}`,
+			wantCode:   http.StatusBadRequest,
+			wantResult: ginx.Result{Code: ginx.ErrBadRequest.Code, Msg: ginx.ErrBadRequest.Msg},
+			wantLevel:  accesslog.ErrorLevel,
+		},
+		{
+			name:     "字段名写错",
+			method:   http.MethodPut,
+			path:     "/levels/grpc",
+			body:     `{"lvl":"debug"}`,
+			wantCode: http.StatusBadRequest,
+			wantResult: ginx.Result{Code: ginx.ErrBadRequest.Code, Msg: ginx.ErrBadRequest.Msg,
+				Data: []any{map[string]any{"field": "Level", "msg": "Key: 'SetLevelRequest.Level' Error:Field validation for 'Level' failed on the 'required' tag"}}},
+			wantLevel: accesslog.ErrorLevel,
+		},
+		{
+			name:       "修改不存在的 logger",
+			method:     http.MethodPut,
+			path:       "/levels/unknown",
+			body:       `{"level":"debug"}`,
+			wantCode:   http.StatusNotFound,
+			wantResult: ginx.Result{Code: ErrLoggerNotFound.Code, Msg: ErrLoggerNotFound.Msg},
+			wantLevel:  accesslog.ErrorLevel,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := accesslog.NewLevelRegistry()
+			// 修改失败的时候，级别保持不变
+			level := accesslog.InfoLevel
+			if tc.method == http.MethodPut {
+				level = accesslog.ErrorLevel
+			}
+			registry.Register("grpc", accesslog.NewNopLogger(), level)
+			server := gin.New()
+			NewHandler(registry).RegisterRoutes(server.Group("/"))
+
+			req, err := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			var res ginx.Result
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+			assert.Equal(t, tc.wantResult, res)
+			l, ok := registry.Get("grpc")
+			require.True(t, ok)
+			assert.Equal(t, tc.wantLevel, l.Level())
+		})
+	}
+}
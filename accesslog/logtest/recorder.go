@@ -0,0 +1,192 @@
+package logtest
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// Entry 一条日志
+type Entry struct {
+	Level accesslog.Level
+	// logger 名称，Named 多次调用用 . 连接
+	Name string
+	Msg  string
+	// 包含 With 绑定的字段，绑定的字段在前
+	Fields []accesslog.Field
+}
+
+// Field 获取字段的值，同名字段以最后一个为准
+func (e Entry) Field(key string) (any, bool) {
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if e.Fields[i].Key == key {
+			return e.Fields[i].Val(), true
+		}
+	}
+	return nil, false
+}
+
+// FieldMap 所有字段，同名字段以最后一个为准
+func (e Entry) FieldMap() map[string]any {
+	res := make(map[string]any, len(e.Fields))
+	for _, f := range e.Fields {
+		res[f.Key] = f.Val()
+	}
+	return res
+}
+
+// AssertField 断言字段的值
+func (e Entry) AssertField(t testing.TB, key string, want any) bool {
+	t.Helper()
+	val, ok := e.Field(key)
+	if !assert.Truef(t, ok, "日志 %q 没有字段 %s", e.Msg, key) {
+		return false
+	}
+	return assert.Equal(t, want, val)
+}
+
+type Entries []Entry
+
+// FilterLevel 过滤指定级别
+func (es Entries) FilterLevel(level accesslog.Level) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Level == level
+	})
+}
+
+// FilterMessage 过滤指定 msg
+func (es Entries) FilterMessage(msg string) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Msg == msg
+	})
+}
+
+// FilterField 过滤带有指定字段值的日志
+func (es Entries) FilterField(key string, val any) Entries {
+	return es.Filter(func(e Entry) bool {
+		v, ok := e.Field(key)
+		return ok && assert.ObjectsAreEqual(val, v)
+	})
+}
+
+func (es Entries) Filter(fn func(e Entry) bool) Entries {
+	res := make(Entries, 0, len(es))
+	for _, e := range es {
+		if fn(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Recorder 记录所有日志，用于测试
+// 并发安全，子 Logger 记录到同一个地方
+type Recorder struct {
+	s      *store
+	name   string
+	fields []accesslog.Field
+}
+
+type store struct {
+	lock    sync.RWMutex
+	entries Entries
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		s: &store{},
+	}
+}
+
+func (r *Recorder) Debug(msg string, args ...accesslog.Field) {
+	r.record(accesslog.DebugLevel, msg, args)
+}
+
+func (r *Recorder) Info(msg string, args ...accesslog.Field) {
+	r.record(accesslog.InfoLevel, msg, args)
+}
+
+func (r *Recorder) Warn(msg string, args ...accesslog.Field) {
+	r.record(accesslog.WarnLevel, msg, args)
+}
+
+func (r *Recorder) Error(msg string, args ...accesslog.Field) {
+	r.record(accesslog.ErrorLevel, msg, args)
+}
+
+func (r *Recorder) WithContext(ctx context.Context) accesslog.Logger {
+	return r.With(accesslog.ContextFields(ctx)...)
+}
+
+func (r *Recorder) With(args ...accesslog.Field) accesslog.Logger {
+	if len(args) == 0 {
+		return r
+	}
+	fields := make([]accesslog.Field, 0, len(r.fields)+len(args))
+	fields = append(fields, r.fields...)
+	fields = append(fields, args...)
+	return &Recorder{
+		s:      r.s,
+		name:   r.name,
+		fields: fields,
+	}
+}
+
+func (r *Recorder) Named(name string) accesslog.Logger {
+	if r.name != "" {
+		name = r.name + "." + name
+	}
+	return &Recorder{
+		s:      r.s,
+		name:   name,
+		fields: r.fields,
+	}
+}
+
+// Entries 已经记录的日志
+func (r *Recorder) Entries() Entries {
+	r.s.lock.RLock()
+	defer r.s.lock.RUnlock()
+	res := make(Entries, len(r.s.entries))
+	copy(res, r.s.entries)
+	return res
+}
+
+func (r *Recorder) Len() int {
+	r.s.lock.RLock()
+	defer r.s.lock.RUnlock()
+	return len(r.s.entries)
+}
+
+// Reset 清空已经记录的日志
+func (r *Recorder) Reset() {
+	r.s.lock.Lock()
+	defer r.s.lock.Unlock()
+	r.s.entries = nil
+}
+
+// AssertLogged 断言记录过指定级别和 msg 的日志，返回第一条
+func (r *Recorder) AssertLogged(t testing.TB, level accesslog.Level, msg string) Entry {
+	t.Helper()
+	es := r.Entries().FilterLevel(level).FilterMessage(msg)
+	if !assert.NotEmptyf(t, es, "没有 %s 级别的日志 %q", level, msg) {
+		return Entry{}
+	}
+	return es[0]
+}
+
+func (r *Recorder) record(level accesslog.Level, msg string, args []accesslog.Field) {
+	fields := make([]accesslog.Field, 0, len(r.fields)+len(args))
+	fields = append(fields, r.fields...)
+	fields = append(fields, args...)
+	r.s.lock.Lock()
+	defer r.s.lock.Unlock()
+	r.s.entries = append(r.s.entries, Entry{
+		Level:  level,
+		Name:   r.name,
+		Msg:    msg,
+		Fields: fields,
+	})
+}
@@ -1,5 +1,7 @@
 package accesslog
 
+import "context"
+
 type NopLogger struct {
 }
 
@@ -18,3 +20,15 @@ func (n *NopLogger) Warn(msg string, args ...Field) {
 
 func (n *NopLogger) Error(msg string, args ...Field) {
 }
+
+func (n *NopLogger) WithContext(ctx context.Context) Logger {
+	return n
+}
+
+func (n *NopLogger) With(args ...Field) Logger {
+	return n
+}
+
+func (n *NopLogger) Named(name string) Logger {
+	return n
+}
@@ -0,0 +1,333 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const defaultMask = "******"
+
+// DefaultSensitiveKeys 常见的敏感字段，不区分大小写
+var DefaultSensitiveKeys = []string{
+	"password", "confirm_password", "token", "access_token", "refresh_token",
+	"authorization", "cookie", "set-cookie", "x-jwt-token", "x-refresh-token",
+}
+
+// secretGroup pattern 里面有这个名字的分组时，只替换分组的内容
+// 用于带边界的格式，例如 PhonePattern 前后的非数字字符不需要替换
+const secretGroup = "secret"
+
+var (
+	// PhonePattern 大陆手机号，前后不能是数字，避免误伤时间戳和订单号之类的长数字
+	PhonePattern = regexp.MustCompile(`(?:^|\D)(?P<secret>1[3-9]\d{9})(?:\D|$)`)
+	// BearerPattern Bearer token
+	BearerPattern = regexp.MustCompile(`(?i)bearer\s+[\w\-.~+/]+=*`)
+)
+
+// Redactor 敏感信息脱敏
+// 配置阶段不是并发安全的，配置完成之后可以并发使用
+type Redactor struct {
+	// 小写的敏感字段名，命中的字段整个替换
+	keys map[string]struct{}
+	// 命中的内容替换为 mask
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+func NewRedactor(keys ...string) *Redactor {
+	r := &Redactor{
+		keys: make(map[string]struct{}, len(keys)),
+		mask: defaultMask,
+	}
+	return r.Keys(keys...)
+}
+
+// Keys 追加敏感字段名，包括 JSON 的 key，Header 名称和日志的字段名
+func (r *Redactor) Keys(keys ...string) *Redactor {
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	return r
+}
+
+// Patterns 追加需要脱敏的内容格式
+// 有名为 secret 的分组的时候，只替换分组的内容
+func (r *Redactor) Patterns(patterns ...*regexp.Regexp) *Redactor {
+	r.patterns = append(r.patterns, patterns...)
+	return r
+}
+
+// Mask 替换后的内容，默认是 ******
+func (r *Redactor) Mask(mask string) *Redactor {
+	r.mask = mask
+	return r
+}
+
+// IsSensitive key 是否为敏感字段
+func (r *Redactor) IsSensitive(key string) bool {
+	_, ok := r.keys[strings.ToLower(key)]
+	return ok
+}
+
+// RedactString 将命中 patterns 的内容替换掉
+func (r *Redactor) RedactString(s string) string {
+	for _, p := range r.patterns {
+		s = r.redactPattern(p, s)
+	}
+	return s
+}
+
+func (r *Redactor) redactPattern(p *regexp.Regexp, s string) string {
+	idx := p.SubexpIndex(secretGroup)
+	if idx < 0 {
+		return p.ReplaceAllString(s, r.mask)
+	}
+	// 边界字符会被匹配消耗掉，例如 13800138000,13900139000 第一次只能匹配到第一个
+	// 所以再匹配一次，第二次就能匹配到剩下的
+	for i := 0; i < 2; i++ {
+		locs := p.FindAllStringSubmatchIndex(s, -1)
+		if len(locs) == 0 {
+			break
+		}
+		var sb strings.Builder
+		last := 0
+		for _, loc := range locs {
+			start, end := loc[2*idx], loc[2*idx+1]
+			sb.WriteString(s[last:start])
+			sb.WriteString(r.mask)
+			last = end
+		}
+		sb.WriteString(s[last:])
+		s = sb.String()
+	}
+	return s
+}
+
+// RedactValue 脱敏 key=val 形式的数据，例如 Header 和 url 参数
+func (r *Redactor) RedactValue(key, val string) string {
+	if r.IsSensitive(key) {
+		return r.mask
+	}
+	return r.RedactString(val)
+}
+
+// RedactJSON 脱敏 JSON 数据
+// 不是合法的 JSON，就按照普通字符串处理
+func (r *Redactor) RedactJSON(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// 避免数字丢失精度
+	decoder.UseNumber()
+	var val any
+	if err := decoder.Decode(&val); err != nil {
+		return []byte(r.RedactString(string(data)))
+	}
+	val, changed := r.redactValue(val)
+	if !changed {
+		return data
+	}
+	res, err := json.Marshal(val)
+	if err != nil {
+		return []byte(r.RedactString(string(data)))
+	}
+	return res
+}
+
+func (r *Redactor) redactValue(val any) (any, bool) {
+	switch v := val.(type) {
+	case map[string]any:
+		changed := false
+		for key, sub := range v {
+			if r.IsSensitive(key) {
+				v[key] = r.mask
+				changed = true
+				continue
+			}
+			newSub, subChanged := r.redactValue(sub)
+			if subChanged {
+				v[key] = newSub
+				changed = true
+			}
+		}
+		return v, changed
+	case []any:
+		changed := false
+		for i, sub := range v {
+			newSub, subChanged := r.redactValue(sub)
+			if subChanged {
+				v[i] = newSub
+				changed = true
+			}
+		}
+		return v, changed
+	case string:
+		res := r.RedactString(v)
+		return res, res != v
+	default:
+		return val, false
+	}
+}
+
+// RedactField 脱敏日志字段
+func (r *Redactor) RedactField(f Field) Field {
+	if r.IsSensitive(f.Key) {
+		return String(f.Key, r.mask)
+	}
+	if f.Type == StringType {
+		f.String = r.RedactString(f.String)
+		return f
+	}
+	switch v := f.Value.(type) {
+	case string:
+		f.Value = r.RedactString(v)
+	case []string:
+		if res, ok := r.redactStrings(v); ok {
+			return Strings(f.Key, res)
+		}
+	case error:
+		msg := v.Error()
+		if res := r.RedactString(msg); res != msg {
+			return String(f.Key, res)
+		}
+	case ObjectMarshaler:
+		if f.Type == ObjectType {
+			return Object(f.Key, redactedObject{obj: v, r: r})
+		}
+	case fmt.Stringer:
+		// 只能先调用 String 方法，没有敏感信息的时候还是延迟到输出的时候
+		if f.Type == StringerType {
+			str := v.String()
+			if res := r.RedactString(str); res != str {
+				return String(f.Key, res)
+			}
+		}
+	default:
+		if f.Type == AnyType {
+			if val, ok := r.redactAny(v); ok {
+				return Any(f.Key, val)
+			}
+		}
+	}
+	return f
+}
+
+// redactStrings 有变化的时候返回新的切片，不修改原本的切片
+func (r *Redactor) redactStrings(vals []string) ([]string, bool) {
+	var res []string
+	for i, val := range vals {
+		redacted := r.RedactString(val)
+		if redacted == val {
+			continue
+		}
+		if res == nil {
+			res = make([]string, len(vals))
+			copy(res, vals)
+		}
+		res[i] = redacted
+	}
+	return res, res != nil
+}
+
+// redactAny 脱敏 map, 结构体和切片之类的值
+// 转成 JSON 之后按照 RedactJSON 的规则处理，有变化的时候返回处理之后的值
+func (r *Redactor) redactAny(val any) (any, bool) {
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+	default:
+		return nil, false
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var res any
+	if err = decoder.Decode(&res); err != nil {
+		return nil, false
+	}
+	return r.redactValue(res)
+}
+
+// redactedObject 输出的时候才脱敏嵌套的字段
+type redactedObject struct {
+	obj ObjectMarshaler
+	r   *Redactor
+}
+
+func (o redactedObject) MarshalLogFields() []Field {
+	return o.r.redactFields(o.obj.MarshalLogFields())
+}
+
+func (r *Redactor) redactFields(args []Field) []Field {
+	if len(args) == 0 {
+		return args
+	}
+	res := make([]Field, 0, len(args))
+	for _, arg := range args {
+		res = append(res, r.RedactField(arg))
+	}
+	return res
+}
+
+// RedactLogger 脱敏装饰器
+// 在日志到达真正的 Logger 之前，替换掉敏感信息
+type RedactLogger struct {
+	l Logger
+	r *Redactor
+}
+
+func NewRedactLogger(l Logger, r *Redactor) Logger {
+	return &RedactLogger{
+		l: l,
+		r: r,
+	}
+}
+
+func (d *RedactLogger) Debug(msg string, args ...Field) {
+	d.l.Debug(d.r.RedactString(msg), d.r.redactFields(args)...)
+}
+
+func (d *RedactLogger) Info(msg string, args ...Field) {
+	d.l.Info(d.r.RedactString(msg), d.r.redactFields(args)...)
+}
+
+func (d *RedactLogger) Warn(msg string, args ...Field) {
+	d.l.Warn(d.r.RedactString(msg), d.r.redactFields(args)...)
+}
+
+func (d *RedactLogger) Error(msg string, args ...Field) {
+	d.l.Error(d.r.RedactString(msg), d.r.redactFields(args)...)
+}
+
+func (d *RedactLogger) WithContext(ctx context.Context) Logger {
+	return &RedactLogger{
+		l: d.l.WithContext(ctx),
+		r: d.r,
+	}
+}
+
+func (d *RedactLogger) With(args ...Field) Logger {
+	return &RedactLogger{
+		l: d.l.With(d.r.redactFields(args)...),
+		r: d.r,
+	}
+}
+
+func (d *RedactLogger) Named(name string) Logger {
+	return &RedactLogger{
+		l: d.l.Named(name),
+		r: d.r,
+	}
+}
@@ -0,0 +1,149 @@
+package accesslog
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestRedactor_RedactJSON(t *testing.T) {
+	r := NewRedactor("password", "Token").Patterns(PhonePattern)
+	testCases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "嵌套字段",
+			data: `{"user":{"password":"123456","name":"qinye"},"token":"abc","id":12345678901234567}`,
+			want: `{"id":12345678901234567,"token":"******","user":{"name":"qinye","password":"******"}}`,
+		},
+		{
+			name: "数组和格式",
+			data: `[{"phone":"13800138000"},{"TOKEN":"abc"}]`,
+			want: `[{"phone":"******"},{"TOKEN":"******"}]`,
+		},
+		{
+			name: "没有敏感信息保持原样",
+			data: `{"b":1, "a":2}`,
+			want: `{"b":1, "a":2}`,
+		},
+		{
+			name: "不是 JSON",
+			data: `phone=13800138000`,
+			want: `phone=******`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, string(r.RedactJSON([]byte(tc.data))))
+		})
+	}
+}
+
+func TestRedactor_RedactString(t *testing.T) {
+	r := NewRedactor().Patterns(PhonePattern)
+	testCases := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "手机号", s: "用户 13800138000 登录", want: "用户 ****** 登录"},
+		{name: "开头和结尾", s: "13800138000", want: "******"},
+		{name: "连续的手机号", s: "13800138000,13900139000,13700137000", want: "******,******,******"},
+		{name: "毫秒时间戳", s: "ctime=1700000000000", want: "ctime=1700000000000"},
+		{name: "更长的数字", s: "order_id=2138001380001", want: "order_id=2138001380001"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, r.RedactString(tc.s))
+		})
+	}
+}
+
+func TestRedactor_RedactField(t *testing.T) {
+	r := NewRedactor(DefaultSensitiveKeys...).Patterns(PhonePattern)
+	type loginReq struct {
+		Phone    string `json:"phone"`
+		Password string
+	}
+	testCases := []struct {
+		name string
+		f    Field
+		want any
+	}{
+		{
+			name: "map",
+			f:    Any("req", map[string]any{"password": "123456", "name": "qinye"}),
+			want: map[string]any{"password": "******", "name": "qinye"},
+		},
+		{
+			name: "结构体指针",
+			f:    Any("req", &loginReq{Phone: "13800138000", Password: "123456"}),
+			want: map[string]any{"phone": "******", "Password": "******"},
+		},
+		{
+			name: "没有敏感信息保持原样",
+			f:    Any("req", map[string]any{"name": "qinye"}),
+			want: map[string]any{"name": "qinye"},
+		},
+		{
+			name: "数字",
+			f:    Any("cnt", 1),
+			want: 1,
+		},
+		{
+			name: "字符串切片",
+			f:    Strings("phones", []string{"13800138000", "qinye"}),
+			want: []string{"******", "qinye"},
+		},
+		{
+			name: "Stringer",
+			f:    Stringer("addr", testStringer("13800138000")),
+			want: "******",
+		},
+		{
+			name: "没有敏感信息的 Stringer 保持原样",
+			f:    Stringer("addr", testStringer("qinye")),
+			want: testStringer("qinye"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, r.RedactField(tc.f).Val())
+		})
+	}
+}
+
+type testStringer string
+
+func (s testStringer) String() string {
+	return string(s)
+}
+
+func TestRedactLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	r := NewRedactor(DefaultSensitiveKeys...).Patterns(PhonePattern)
+	l := NewRedactLogger(NewZapLogger(zap.New(core)), r).
+		With(String("authorization", "Bearer abc"))
+	l.Error("用户 13800138000 登录失败",
+		String("password", "123456"),
+		String("phone", "13800138000"),
+		Error(errors.New("手机号 13800138000 不存在")),
+		Int64("uid", 1))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "用户 ****** 登录失败", entries[0].Message)
+	assert.Equal(t, map[string]any{
+		"authorization": "******",
+		"password":      "******",
+		"phone":         "******",
+		"error":         "手机号 ****** 不存在",
+		"uid":           int64(1),
+	}, entries[0].ContextMap())
+}
@@ -0,0 +1,152 @@
+package accesslog
+
+import (
+	"context"
+	"go.uber.org/atomic"
+	"sync"
+	"time"
+)
+
+// 最多统计的 key 数量，避免 msg 是动态拼接的时候内存无限增长
+const maxSampleKeys = 4096
+
+// SampledLogger 日志采样
+// 按照 级别+msg 计数，每个 interval 内前 first 条正常输出，之后每 thereafter 条输出一条
+// 被采样掉的条数会附带在下一条输出的日志上，字段名是 sampled_dropped
+// 用于消息反序列化失败，数据库出错这种在循环里面刷屏的日志
+type SampledLogger struct {
+	l Logger
+	s *sampler
+}
+
+// NewSampledLogger 创建采样 Logger
+// thereafter <= 0 代表超过 first 条之后全部丢弃
+func NewSampledLogger(l Logger, interval time.Duration, first, thereafter int) *SampledLogger {
+	return &SampledLogger{
+		l: l,
+		s: &sampler{
+			interval:   interval,
+			first:      int64(first),
+			thereafter: int64(thereafter),
+			counters:   make(map[sampleKey]*sampleCounter),
+			dropped:    atomic.NewInt64(0),
+			now:        time.Now,
+		},
+	}
+}
+
+// Dropped 累计被丢弃的日志条数
+func (d *SampledLogger) Dropped() int64 {
+	return d.s.dropped.Load()
+}
+
+func (d *SampledLogger) Debug(msg string, args ...Field) {
+	if args, ok := d.s.sample(DebugLevel, msg, args); ok {
+		d.l.Debug(msg, args...)
+	}
+}
+
+func (d *SampledLogger) Info(msg string, args ...Field) {
+	if args, ok := d.s.sample(InfoLevel, msg, args); ok {
+		d.l.Info(msg, args...)
+	}
+}
+
+func (d *SampledLogger) Warn(msg string, args ...Field) {
+	if args, ok := d.s.sample(WarnLevel, msg, args); ok {
+		d.l.Warn(msg, args...)
+	}
+}
+
+func (d *SampledLogger) Error(msg string, args ...Field) {
+	if args, ok := d.s.sample(ErrorLevel, msg, args); ok {
+		d.l.Error(msg, args...)
+	}
+}
+
+func (d *SampledLogger) WithContext(ctx context.Context) Logger {
+	return &SampledLogger{
+		l: d.l.WithContext(ctx),
+		s: d.s,
+	}
+}
+
+func (d *SampledLogger) With(args ...Field) Logger {
+	return &SampledLogger{
+		l: d.l.With(args...),
+		s: d.s,
+	}
+}
+
+func (d *SampledLogger) Named(name string) Logger {
+	return &SampledLogger{
+		l: d.l.Named(name),
+		s: d.s,
+	}
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCounter struct {
+	// 当前窗口的结束时间
+	resetAt time.Time
+	count   int64
+	// 上一次输出之后丢弃的条数
+	dropped int64
+}
+
+// sampler 子 Logger 共享同一个 sampler
+type sampler struct {
+	interval   time.Duration
+	first      int64
+	thereafter int64
+
+	lock     sync.Mutex
+	counters map[sampleKey]*sampleCounter
+	dropped  *atomic.Int64
+	now      func() time.Time
+}
+
+// sample 判断是否输出
+// 如果之前有丢弃的日志，会在 args 里面追加丢弃的条数
+func (s *sampler) sample(level Level, msg string, args []Field) ([]Field, bool) {
+	now := s.now()
+	key := sampleKey{level: level, msg: msg}
+
+	s.lock.Lock()
+	c, ok := s.counters[key]
+	if !ok {
+		if len(s.counters) >= maxSampleKeys {
+			s.counters = make(map[sampleKey]*sampleCounter)
+		}
+		c = &sampleCounter{}
+		s.counters[key] = c
+	}
+	if !now.Before(c.resetAt) {
+		// 进入新的窗口
+		c.resetAt = now.Add(s.interval)
+		c.count = 0
+	}
+	c.count++
+	pass := c.count <= s.first ||
+		(s.thereafter > 0 && (c.count-s.first)%s.thereafter == 0)
+	if !pass {
+		c.dropped++
+		s.lock.Unlock()
+		s.dropped.Inc()
+		return args, false
+	}
+	dropped := c.dropped
+	c.dropped = 0
+	s.lock.Unlock()
+
+	if dropped > 0 {
+		res := make([]Field, 0, len(args)+1)
+		res = append(res, args...)
+		args = append(res, Int64("sampled_dropped", dropped))
+	}
+	return args, true
+}
@@ -0,0 +1,47 @@
+package accesslog
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+func TestSampledLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewSampledLogger(NewZapLogger(zap.New(core)), time.Second, 2, 3)
+	now := time.UnixMilli(1000)
+	l.s.now = func() time.Time {
+		return now
+	}
+
+	// 第 1,2 条正常输出，之后每 3 条输出一条，也就是第 5，8 条
+	for i := 0; i < 9; i++ {
+		l.Error("反序列消息失败", Int64("offset", int64(i)))
+	}
+	// 不同的 msg 分开计数
+	l.Error("处理消息失败")
+	// 不同的级别分开计数
+	l.Warn("反序列消息失败")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 6)
+	assert.Equal(t, int64(4), entries[2].ContextMap()["offset"])
+	assert.Equal(t, int64(2), entries[2].ContextMap()["sampled_dropped"])
+	assert.Equal(t, int64(7), entries[3].ContextMap()["offset"])
+	assert.Equal(t, int64(2), entries[3].ContextMap()["sampled_dropped"])
+	assert.Equal(t, int64(5), l.Dropped())
+
+	// 进入下一个窗口，重新计数，并带上上个窗口丢弃的条数
+	now = now.Add(time.Second)
+	l.With(String("topic", "test")).Error("反序列消息失败")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{
+		"topic":           "test",
+		"sampled_dropped": int64(1),
+	}, entries[0].ContextMap())
+}
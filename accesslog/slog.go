@@ -0,0 +1,223 @@
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime"
+	"time"
+)
+
+// SlogLogger 使用 slog.Handler 实现 Logger
+type SlogLogger struct {
+	handler slog.Handler
+	ctx     context.Context
+	name    string
+}
+
+// NewSlogLogger 将任意 slog.Handler 适配为 Logger
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &SlogLogger{
+		handler: handler,
+		ctx:     context.Background(),
+	}
+}
+
+func (s *SlogLogger) Debug(msg string, args ...Field) {
+	s.log(slog.LevelDebug, msg, args)
+}
+
+func (s *SlogLogger) Info(msg string, args ...Field) {
+	s.log(slog.LevelInfo, msg, args)
+}
+
+func (s *SlogLogger) Warn(msg string, args ...Field) {
+	s.log(slog.LevelWarn, msg, args)
+}
+
+func (s *SlogLogger) Error(msg string, args ...Field) {
+	s.log(slog.LevelError, msg, args)
+}
+
+func (s *SlogLogger) WithContext(ctx context.Context) Logger {
+	res := *s
+	res.ctx = ctx
+	fields := ContextFields(ctx)
+	if len(fields) > 0 {
+		res.handler = s.handler.WithAttrs(toSlogAttrs(fields))
+	}
+	return &res
+}
+
+func (s *SlogLogger) With(args ...Field) Logger {
+	if len(args) == 0 {
+		return s
+	}
+	res := *s
+	res.handler = s.handler.WithAttrs(toSlogAttrs(args))
+	return &res
+}
+
+// Named slog 没有 logger 名称的概念，这里使用 logger 字段输出
+func (s *SlogLogger) Named(name string) Logger {
+	res := *s
+	if s.name == "" {
+		res.name = name
+	} else {
+		res.name = s.name + "." + name
+	}
+	return &res
+}
+
+func (s *SlogLogger) log(level slog.Level, msg string, args []Field) {
+	if !s.handler.Enabled(s.ctx, level) {
+		return
+	}
+	// 跳过 runtime.Callers, log, 以及 Debug/Info 等方法本身
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if s.name != "" {
+		r.AddAttrs(slog.String("logger", s.name))
+	}
+	r.AddAttrs(toSlogAttrs(args)...)
+	_ = s.handler.Handle(s.ctx, r)
+}
+
+func toSlogAttrs(args []Field) []slog.Attr {
+	res := make([]slog.Attr, 0, len(args))
+	for _, arg := range args {
+		res = append(res, toSlogAttr(arg))
+	}
+	return res
+}
+
+// toSlogAttr 根据 Type 转换为 slog 的强类型 Attr
+func toSlogAttr(arg Field) slog.Attr {
+	switch arg.Type {
+	case StringType:
+		return slog.String(arg.Key, arg.String)
+	case Int32Type, Int64Type:
+		return slog.Int64(arg.Key, arg.Integer)
+	case BoolType:
+		return slog.Bool(arg.Key, arg.Integer == 1)
+	case DurationType:
+		return slog.Duration(arg.Key, time.Duration(arg.Integer))
+	case Float64Type:
+		return slog.Float64(arg.Key, math.Float64frombits(uint64(arg.Integer)))
+	case Uint64Type:
+		return slog.Uint64(arg.Key, uint64(arg.Integer))
+	case TimeType:
+		if v, ok := arg.Value.(time.Time); ok {
+			return slog.Time(arg.Key, v)
+		}
+	case StringerType:
+		if v, ok := arg.Value.(fmt.Stringer); ok {
+			return slog.String(arg.Key, v.String())
+		}
+	case ObjectType:
+		if v, ok := arg.Value.(ObjectMarshaler); ok {
+			return slog.Attr{Key: arg.Key, Value: slog.GroupValue(toSlogAttrs(v.MarshalLogFields())...)}
+		}
+	}
+	return slog.Any(arg.Key, arg.Value)
+}
+
+// SlogHandler 实现 slog.Handler，将日志转发到 Logger
+type SlogHandler struct {
+	l     Logger
+	level slog.Leveler
+	// 当前所在的分组，作为字段名的前缀
+	prefix string
+}
+
+// NewSlogHandler 将 Logger 适配为 slog.Handler
+// level 为 nil 时，默认为 slog.LevelDebug，由 Logger 自己决定是否输出
+func NewSlogHandler(l Logger, level slog.Leveler) *SlogHandler {
+	if level == nil {
+		level = slog.LevelDebug
+	}
+	return &SlogHandler{
+		l:     l,
+		level: level,
+	}
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, attr)
+		return true
+	})
+	l := h.l.WithContext(ctx)
+	switch {
+	case r.Level >= slog.LevelError:
+		l.Error(r.Message, fields...)
+	case r.Level >= slog.LevelWarn:
+		l.Warn(r.Message, fields...)
+	case r.Level >= slog.LevelInfo:
+		l.Info(r.Message, fields...)
+	default:
+		l.Debug(r.Message, fields...)
+	}
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, attr)
+	}
+	return &SlogHandler{
+		l:      h.l.With(fields...),
+		level:  h.level,
+		prefix: h.prefix,
+	}
+}
+
+// WithGroup 分组会被展开为 group.key 形式的字段名
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{
+		l:      h.l,
+		level:  h.level,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// appendSlogAttr 将 slog.Attr 转换为 Field
+// 嵌套的分组会被展开
+func appendSlogAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	val := attr.Value.Resolve()
+	// slog 约定：忽略空的 Attr
+	if attr.Key == "" && val.Kind() != slog.KindGroup {
+		return fields
+	}
+	switch val.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		// 没有 key 的分组直接展开到当前层级
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, a := range val.Group() {
+			fields = appendSlogAttr(fields, groupPrefix, a)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, String(prefix+attr.Key, val.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(prefix+attr.Key, val.Int64()))
+	case slog.KindBool:
+		return append(fields, Bool(prefix+attr.Key, val.Bool()))
+	default:
+		return append(fields, Any(prefix+attr.Key, val.Any()))
+	}
+}
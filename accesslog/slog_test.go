@@ -0,0 +1,61 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewSlogLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	// 低于 Info 级别不输出
+	l.Debug("debug")
+	l.Named("saramax").With(String("topic", "test_topic")).
+		Warn("处理消息失败", Int64("offset", 10), Error(errors.New("mock error")))
+
+	var res map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+	delete(res, "time")
+	assert.Equal(t, map[string]any{
+		"level":  "WARN",
+		"msg":    "处理消息失败",
+		"logger": "saramax",
+		"topic":  "test_topic",
+		"offset": float64(10),
+		"error":  "mock error",
+	}, res)
+}
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	h := NewSlogHandler(NewZapLogger(zap.New(core)), slog.LevelInfo)
+	l := slog.New(h).With("app", "demo").WithGroup("req")
+
+	l.DebugContext(context.Background(), "debug")
+	l.Warn("请求失败",
+		slog.String("method", "GET"),
+		slog.Int("status", 500),
+		slog.Group("user", slog.Int64("id", 1)))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "请求失败", entries[0].Message)
+	assert.Equal(t, map[string]any{
+		"app":         "demo",
+		"req.method":  "GET",
+		"req.status":  int64(500),
+		"req.user.id": int64(1),
+	}, entries[0].ContextMap())
+}
@@ -0,0 +1,76 @@
+package accesslog
+
+import "context"
+
+// TeeSink 接收日志的 Logger
+type TeeSink struct {
+	Logger Logger
+	// MinLevel 大于等于这个级别的日志才会发给 Logger
+	// 零值是 InfoLevel，需要 Debug 日志要显式设置为 DebugLevel
+	MinLevel Level
+}
+
+// TeeLogger 按照级别把日志分发给多个 Logger
+// 例如所有日志都写主日志，Error 日志额外写一份到告警文件
+type TeeLogger struct {
+	sinks []TeeSink
+}
+
+func NewTeeLogger(sinks ...TeeSink) *TeeLogger {
+	return &TeeLogger{
+		sinks: sinks,
+	}
+}
+
+func (t *TeeLogger) Debug(msg string, args ...Field) {
+	t.log(DebugLevel, msg, args)
+}
+
+func (t *TeeLogger) Info(msg string, args ...Field) {
+	t.log(InfoLevel, msg, args)
+}
+
+func (t *TeeLogger) Warn(msg string, args ...Field) {
+	t.log(WarnLevel, msg, args)
+}
+
+func (t *TeeLogger) Error(msg string, args ...Field) {
+	t.log(ErrorLevel, msg, args)
+}
+
+func (t *TeeLogger) WithContext(ctx context.Context) Logger {
+	return t.derive(func(l Logger) Logger {
+		return l.WithContext(ctx)
+	})
+}
+
+func (t *TeeLogger) With(args ...Field) Logger {
+	return t.derive(func(l Logger) Logger {
+		return l.With(args...)
+	})
+}
+
+func (t *TeeLogger) Named(name string) Logger {
+	return t.derive(func(l Logger) Logger {
+		return l.Named(name)
+	})
+}
+
+func (t *TeeLogger) derive(fn func(l Logger) Logger) Logger {
+	sinks := make([]TeeSink, 0, len(t.sinks))
+	for _, s := range t.sinks {
+		sinks = append(sinks, TeeSink{
+			Logger:   fn(s.Logger),
+			MinLevel: s.MinLevel,
+		})
+	}
+	return &TeeLogger{sinks: sinks}
+}
+
+func (t *TeeLogger) log(level Level, msg string, args []Field) {
+	for _, s := range t.sinks {
+		if level >= s.MinLevel {
+			logAt(s.Logger, level, msg, args)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package accesslog
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestTeeLogger(t *testing.T) {
+	mainCore, mainLogs := observer.New(zapcore.DebugLevel)
+	alertCore, alertLogs := observer.New(zapcore.DebugLevel)
+	l := NewTeeLogger(
+		TeeSink{Logger: NewZapLogger(zap.New(mainCore)), MinLevel: DebugLevel},
+		TeeSink{Logger: NewZapLogger(zap.New(alertCore)), MinLevel: ErrorLevel},
+	).With(String("biz", "test"))
+
+	l.Debug("debug")
+	l.Warn("warn")
+	l.Error("error")
+
+	assert.Equal(t, 3, mainLogs.Len())
+	entries := alertLogs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "error", entries[0].Message)
+	assert.Equal(t, map[string]any{"biz": "test"}, entries[0].ContextMap())
+}
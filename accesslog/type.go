@@ -1,13 +1,82 @@
 package accesslog
 
+import (
+	"context"
+	"math"
+	"time"
+)
+
 type Logger interface {
 	Debug(msg string, args ...Field)
 	Info(msg string, args ...Field)
 	Warn(msg string, args ...Field)
 	Error(msg string, args ...Field)
+	// WithContext 返回绑定了 ctx 的 Logger
+	// 会自动带上 ctx 中的 trace_id 和 span_id，便于和链路追踪关联
+	WithContext(ctx context.Context) Logger
+	// With 返回绑定了 fields 的子 Logger，之后的每条日志都会带上这些字段
+	With(args ...Field) Logger
+	// Named 返回指定名称的子 Logger，多次调用名称用 . 连接
+	Named(name string) Logger
 }
 
 type Field struct {
-	Key   string
+	Key string
+	// Value 类型未知，或者无法用 Integer 和 String 存储的值
+	// String Int32 Int64 Bool 这些强类型字段的值不在 Value 里面
+	// 读取字段的值请使用 Val 方法
 	Value any
+	// Type 标记值的类型，Logger 实现可以据此避免反射
+	// 零值 AnyType 代表类型未知，值在 Value 里面
+	Type FieldType
+	// Integer 和 String 存储强类型的值，避免装箱带来的内存分配
+	Integer int64
+	String  string
+}
+
+// Val 返回字段的值，强类型字段会还原为对应的类型
+func (f Field) Val() any {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case Int32Type:
+		return int32(f.Integer)
+	case Int64Type:
+		return f.Integer
+	case BoolType:
+		return f.Integer == 1
+	case DurationType:
+		return time.Duration(f.Integer)
+	case Float64Type:
+		return math.Float64frombits(uint64(f.Integer))
+	case Uint64Type:
+		return uint64(f.Integer)
+	default:
+		return f.Value
+	}
+}
+
+// FieldType 字段类型
+type FieldType uint8
+
+const (
+	AnyType FieldType = iota
+	StringType
+	Int32Type
+	Int64Type
+	BoolType
+	ErrorType
+	DurationType
+	TimeType
+	Float64Type
+	Uint64Type
+	StringsType
+	StringerType
+	ObjectType
+)
+
+// ObjectMarshaler 由对象自己决定输出哪些字段
+// 用于替代 Any，避免反射序列化整个结构体
+type ObjectMarshaler interface {
+	MarshalLogFields() []Field
 }
@@ -1,9 +1,18 @@
 package accesslog
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"math"
+	"time"
+)
 
 type ZapLogger struct {
 	log *zap.Logger
+	// level 创建 core 时使用的级别，为 nil 的时候 SetLevel 不生效
+	level *zap.AtomicLevel
 }
 
 // NewZapLogger 实现自定义 Logger
@@ -13,26 +22,139 @@ func NewZapLogger(log *zap.Logger) Logger {
 	}
 }
 
+// NewZapLoggerWithLevel level 必须是创建 log 的 core 时传入的 zap.AtomicLevel
+// 这样 SetLevel 调整的是 core 本身的级别，core 是 Info 的时候也能打开 Debug
+func NewZapLoggerWithLevel(log *zap.Logger, level zap.AtomicLevel) Logger {
+	return &ZapLogger{
+		log:   log,
+		level: &level,
+	}
+}
+
+// SetLevel 修改 core 的级别，子 Logger 共享同一个级别
+func (z *ZapLogger) SetLevel(level Level) {
+	if z.level == nil {
+		return
+	}
+	z.level.SetLevel(zapcore.Level(level))
+}
+
 func (z *ZapLogger) Debug(msg string, args ...Field) {
-	z.log.Debug(msg, z.toZapFileds(args)...)
+	z.log.Debug(msg, toZapFields(args)...)
 }
 
 func (z *ZapLogger) Info(msg string, args ...Field) {
-	z.log.Info(msg, z.toZapFileds(args)...)
+	z.log.Info(msg, toZapFields(args)...)
 }
 
 func (z *ZapLogger) Warn(msg string, args ...Field) {
-	z.log.Warn(msg, z.toZapFileds(args)...)
+	z.log.Warn(msg, toZapFields(args)...)
 }
 
 func (z *ZapLogger) Error(msg string, args ...Field) {
-	z.log.Error(msg, z.toZapFileds(args)...)
+	z.log.Error(msg, toZapFields(args)...)
 }
 
-func (z *ZapLogger) toZapFileds(args []Field) []zap.Field {
-	res := make([]zap.Field, 0, len(args))
-	for _, arg := range args {
-		res = append(res, zap.Any(arg.Key, arg.Value))
+// WithContext 从 ctx 中提取链路信息，生成子 Logger
+func (z *ZapLogger) WithContext(ctx context.Context) Logger {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return z
+	}
+	return &ZapLogger{
+		log:   z.log.With(toZapFields(fields)...),
+		level: z.level,
+	}
+}
+
+func (z *ZapLogger) With(args ...Field) Logger {
+	if len(args) == 0 {
+		return z
+	}
+	return &ZapLogger{
+		log:   z.log.With(toZapFields(args)...),
+		level: z.level,
+	}
+}
+
+func (z *ZapLogger) Named(name string) Logger {
+	return &ZapLogger{
+		log:   z.log.Named(name),
+		level: z.level,
+	}
+}
+
+// toZapFields 对象的值放在 len(args) 之后的位置，zapObject 直接指向这个位置
+// 这样所有字段只需要分配一次内存，不用给每个对象单独分配一个 zapObject
+func toZapFields(args []Field) []zap.Field {
+	objects := 0
+	for i := range args {
+		if args[i].Type == ObjectType {
+			objects++
+		}
+	}
+	res := make([]zap.Field, len(args), len(args)+objects)
+	for i, arg := range args {
+		if v, ok := arg.Value.(ObjectMarshaler); ok && arg.Type == ObjectType {
+			res = append(res, zap.Field{Interface: v})
+			res[i] = zap.Object(arg.Key, (*zapObject)(&res[len(res)-1]))
+			continue
+		}
+		res[i] = toZapField(arg)
+	}
+	// 限制容量，避免 core 里面 append 的时候覆盖掉对象的值
+	return res[:len(args):len(args)]
+}
+
+// toZapField 根据 Type 直接转换为 zap 的强类型字段，避免 zap.Any 的类型判断和反射
+// Value 和 Type 对不上的时候，退化为 zap.Any
+func toZapField(arg Field) zap.Field {
+	switch arg.Type {
+	case StringType:
+		return zap.String(arg.Key, arg.String)
+	case Int32Type:
+		return zap.Int32(arg.Key, int32(arg.Integer))
+	case Int64Type:
+		return zap.Int64(arg.Key, arg.Integer)
+	case BoolType:
+		return zap.Bool(arg.Key, arg.Integer == 1)
+	case DurationType:
+		return zap.Duration(arg.Key, time.Duration(arg.Integer))
+	case Float64Type:
+		return zap.Float64(arg.Key, math.Float64frombits(uint64(arg.Integer)))
+	case Uint64Type:
+		return zap.Uint64(arg.Key, uint64(arg.Integer))
+	case ErrorType:
+		if v, ok := arg.Value.(error); ok {
+			return zap.NamedError(arg.Key, v)
+		}
+	case TimeType:
+		if v, ok := arg.Value.(time.Time); ok {
+			return zap.Time(arg.Key, v)
+		}
+	case StringsType:
+		if v, ok := arg.Value.([]string); ok {
+			return zap.Strings(arg.Key, v)
+		}
+	case StringerType:
+		if v, ok := arg.Value.(fmt.Stringer); ok {
+			return zap.Stringer(arg.Key, v)
+		}
+	case ObjectType:
+		if v, ok := arg.Value.(ObjectMarshaler); ok {
+			return zap.Object(arg.Key, &zapObject{Interface: v})
+		}
+	}
+	return zap.Any(arg.Key, arg.Value)
+}
+
+// zapObject 适配 zapcore.ObjectMarshaler，Interface 是 ObjectMarshaler
+// 使用 zap.Field 是为了能够和其它字段放在同一个切片里面
+type zapObject zap.Field
+
+func (z *zapObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range z.Interface.(ObjectMarshaler).MarshalLogFields() {
+		toZapField(f).AddTo(enc)
 	}
-	return res
+	return nil
 }
@@ -0,0 +1,206 @@
+package accesslog
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestZapLogger_WithContext(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	spanCtx := trace.ContextWithSpanContext(context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  spanID,
+		}))
+
+	testCases := []struct {
+		name       string
+		ctx        context.Context
+		wantFields map[string]any
+	}{
+		{
+			name: "带链路信息",
+			ctx:  spanCtx,
+			wantFields: map[string]any{
+				"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span_id":  "00f067aa0ba902b7",
+				"biz":      "test",
+			},
+		},
+		{
+			name: "带 request id",
+			ctx:  requestid.NewContext(spanCtx, "req-123"),
+			wantFields: map[string]any{
+				"request_id": "req-123",
+				"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span_id":    "00f067aa0ba902b7",
+				"biz":        "test",
+			},
+		},
+		{
+			name: "只有 request id",
+			ctx:  requestid.NewContext(context.Background(), "req-123"),
+			wantFields: map[string]any{
+				"request_id": "req-123",
+				"biz":        "test",
+			},
+		},
+		{
+			name: "没有链路信息",
+			ctx:  context.Background(),
+			wantFields: map[string]any{
+				"biz": "test",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			l := NewZapLogger(zap.New(core))
+			l.WithContext(tc.ctx).Info("hello", String("biz", "test"))
+
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.wantFields, entries[0].ContextMap())
+		})
+	}
+}
+
+func TestZapLogger_WithAndNamed(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewZapLogger(zap.New(core)).
+		Named("saramax").
+		With(String("topic", "test_topic"), Int32("partition", 1))
+	l.Named("handler").Error("反序列消息失败", Int64("offset", 10))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "saramax.handler", entries[0].LoggerName)
+	assert.Equal(t, map[string]any{
+		"topic":     "test_topic",
+		"partition": int32(1),
+		"offset":    int64(10),
+	}, entries[0].ContextMap())
+}
+
+func TestZapLogger_TypedFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewZapLogger(zap.New(core))
+	now := time.UnixMilli(1700000000000)
+	l.Info("typed",
+		Duration("cost", time.Second),
+		Time("ctime", now),
+		Float64("ratio", 0.5),
+		Uint64("count", 10),
+		Strings("tags", []string{"a", "b"}),
+		Stringer("level", WarnLevel),
+		Object("user", &benchUser{Id: 1, Name: "qinye"}),
+		Object("author", &benchUser{Id: 2, Name: "dada"}),
+		// 没有类型的字段，使用 zap.Any
+		Field{Key: "event", Value: map[string]any{"id": 1}})
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{
+		"cost":   time.Second,
+		"ctime":  now,
+		"ratio":  0.5,
+		"count":  uint64(10),
+		"tags":   []any{"a", "b"},
+		"level":  "warn",
+		"user":   map[string]any{"id": int64(1), "name": "qinye"},
+		"author": map[string]any{"id": int64(2), "name": "dada"},
+		"event":  map[string]any{"id": 1},
+	}, entries[0].ContextMap())
+}
+
+type benchUser struct {
+	Id    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	Ctime int64  `json:"ctime"`
+	Utime int64  `json:"utime"`
+}
+
+func (u *benchUser) MarshalLogFields() []Field {
+	fields := make([]Field, 0, 6)
+	fields = append(fields, Int64("id", u.Id), String("name", u.Name))
+	if u.Email != "" {
+		fields = append(fields, String("email", u.Email))
+	}
+	if u.Phone != "" {
+		fields = append(fields, String("phone", u.Phone))
+	}
+	if u.Ctime != 0 {
+		fields = append(fields, Int64("ctime", u.Ctime), Int64("utime", u.Utime))
+	}
+	return fields
+}
+
+// BenchmarkZapLogger_Fields 对比强类型字段和 Any
+// go test -run=^$ -bench=BenchmarkZapLogger_Fields -benchmem ./accesslog
+func BenchmarkZapLogger_Fields(b *testing.B) {
+	l := NewZapLogger(zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(io.Discard), zapcore.DebugLevel)))
+	// 模拟 gin 的访问日志，值都是运行期间才确定的
+	al := struct {
+		Method   string
+		Url      string
+		Status   int64
+		Duration time.Duration
+	}{Method: "GET", Url: "/users/profile?id=123", Status: 404, Duration: 35 * time.Millisecond}
+	now := time.Now().UnixMilli()
+	u := &benchUser{Id: 1, Name: "qinye", Email: "qinye@qq.com",
+		Phone: "13800138000", Ctime: now, Utime: now}
+
+	b.Run("primitives/typed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("access",
+				String("method", al.Method),
+				String("url", al.Url),
+				Int64("status", al.Status),
+				Duration("cost", al.Duration))
+		}
+	})
+
+	b.Run("primitives/any", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("access",
+				Any("method", al.Method),
+				Any("url", al.Url),
+				Any("status", al.Status),
+				Any("cost", al.Duration))
+		}
+	})
+
+	b.Run("object/typed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("user", Object("user", u))
+		}
+	})
+
+	b.Run("object/any", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("user", Any("user", u))
+		}
+	})
+}
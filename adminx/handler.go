@@ -0,0 +1,113 @@
+package adminx
+
+import (
+	"github.com/gin-gonic/gin"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+type BuildInfoVO struct {
+	GoVersion string `json:"go_version"`
+	Path      string `json:"path"`
+	Version   string `json:"version"`
+	// vcs.revision, vcs.time 之类的构建参数
+	Settings map[string]string `json:"settings"`
+	Deps     []ModuleVO        `json:"deps"`
+}
+
+type ModuleVO struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+type RuntimeVO struct {
+	Goroutines int `json:"goroutines"`
+	NumCPU     int `json:"num_cpu"`
+	GOMAXPROCS int `json:"gomaxprocs"`
+	// 单位都是字节
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapInuse   uint64 `json:"heap_inuse"`
+	HeapObjects uint64 `json:"heap_objects"`
+	Sys         uint64 `json:"sys"`
+	NumGC       uint32 `json:"num_gc"`
+	// 最近一次 GC 的暂停时间，单位纳秒
+	LastPauseNs uint64 `json:"last_pause_ns"`
+}
+
+type CronEntryVO struct {
+	Cron string `json:"cron"`
+	ID   int    `json:"id"`
+	Prev int64  `json:"prev"`
+	Next int64  `json:"next"`
+}
+
+// BuildInfo 构建信息
+func (s *Server) BuildInfo(ctx *gin.Context) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		s.ok(ctx, BuildInfoVO{GoVersion: runtime.Version()})
+		return
+	}
+	res := BuildInfoVO{
+		GoVersion: info.GoVersion,
+		Path:      info.Path,
+		Version:   info.Main.Version,
+		Settings:  make(map[string]string, len(info.Settings)),
+		Deps:      make([]ModuleVO, 0, len(info.Deps)),
+	}
+	for _, setting := range info.Settings {
+		res.Settings[setting.Key] = setting.Value
+	}
+	for _, dep := range info.Deps {
+		res.Deps = append(res.Deps, ModuleVO{Path: dep.Path, Version: dep.Version})
+	}
+	s.ok(ctx, res)
+}
+
+// Runtime goroutine 和内存信息
+// ReadMemStats 会 STW，不要高频调用
+func (s *Server) Runtime(ctx *gin.Context) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	s.ok(ctx, RuntimeVO{
+		Goroutines:  runtime.NumGoroutine(),
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		HeapAlloc:   stats.HeapAlloc,
+		HeapInuse:   stats.HeapInuse,
+		HeapObjects: stats.HeapObjects,
+		Sys:         stats.Sys,
+		NumGC:       stats.NumGC,
+		LastPauseNs: stats.PauseNs[(stats.NumGC+255)%256],
+	})
+}
+
+// Crons 所有定时任务的调度情况，时间是毫秒时间戳，没有就是 0
+func (s *Server) Crons(ctx *gin.Context) {
+	names := make([]string, 0, len(s.crons))
+	for name := range s.crons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := make([]CronEntryVO, 0, len(names))
+	for _, name := range names {
+		for _, entry := range s.crons[name].Entries() {
+			res = append(res, CronEntryVO{
+				Cron: name,
+				ID:   int(entry.ID),
+				Prev: toMilli(entry.Prev),
+				Next: toMilli(entry.Next),
+			})
+		}
+	}
+	s.ok(ctx, res)
+}
+
+func toMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
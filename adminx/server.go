@@ -0,0 +1,108 @@
+package adminx
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robfig/cron/v3"
+	"net/http"
+	"net/http/pprof"
+	"path"
+)
+
+// Module 管理模块，例如 gormx/migrator/scheduler.Scheduler
+// 和 accesslog/leveladmin.Handler
+type Module interface {
+	RegisterRoutes(server *gin.RouterGroup)
+}
+
+// Server 管理端口
+// 提供 pprof, /metrics, 构建信息, 运行时信息和定时任务
+// 不要把这个端口暴露给外部
+type Server struct {
+	server   *ginx.Server
+	gatherer prometheus.Gatherer
+	crons    map[string]*cron.Cron
+	modules  map[string]Module
+}
+
+func NewServer(addr string) *Server {
+	return &Server{
+		server: &ginx.Server{
+			Engine: gin.New(),
+			Addr:   addr,
+		},
+		gatherer: prometheus.DefaultGatherer,
+		crons:    make(map[string]*cron.Cron),
+		modules:  make(map[string]Module),
+	}
+}
+
+// Gatherer /metrics 使用的 Gatherer，默认是 prometheus.DefaultGatherer
+func (s *Server) Gatherer(gatherer prometheus.Gatherer) *Server {
+	s.gatherer = gatherer
+	return s
+}
+
+// AddCron 添加定时任务，通过 /crons 查看
+func (s *Server) AddCron(name string, c *cron.Cron) *Server {
+	s.crons[name] = c
+	return s
+}
+
+// Mount 把管理模块挂在 prefix 下
+// 例如 Mount("/migrator/interactives", scheduler)
+func (s *Server) Mount(prefix string, m Module) *Server {
+	s.modules[prefix] = m
+	return s
+}
+
+// Engine 用于注册其他路由，例如 healthx.Registry
+func (s *Server) Engine() *gin.Engine {
+	return s.server.Engine
+}
+
+// Start 注册路由并且启动，会阻塞
+func (s *Server) Start() error {
+	s.registerRoutes()
+	return s.server.Start()
+}
+
+// Shutdown 优雅退出
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) registerRoutes() {
+	engine := s.server.Engine
+	s.registerPprof(engine.Group("/debug/pprof"))
+	engine.GET("/metrics", gin.WrapH(promhttp.HandlerFor(s.gatherer, promhttp.HandlerOpts{})))
+	engine.GET("/buildinfo", s.BuildInfo)
+	engine.GET("/runtime", s.Runtime)
+	engine.GET("/crons", s.Crons)
+	for prefix, m := range s.modules {
+		m.RegisterRoutes(engine.Group(prefix))
+	}
+}
+
+func (s *Server) registerPprof(server *gin.RouterGroup) {
+	server.GET("/", gin.WrapF(pprof.Index))
+	server.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	server.GET("/profile", gin.WrapF(pprof.Profile))
+	server.GET("/symbol", gin.WrapF(pprof.Symbol))
+	server.POST("/symbol", gin.WrapF(pprof.Symbol))
+	server.GET("/trace", gin.WrapF(pprof.Trace))
+	// heap, goroutine, allocs 之类的
+	server.GET("/:name", func(ctx *gin.Context) {
+		pprof.Handler(path.Base(ctx.Param("name"))).ServeHTTP(ctx.Writer, ctx.Request)
+	})
+}
+
+func (s *Server) ok(ctx *gin.Context, data any) {
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg:  "OK",
+		Data: data,
+	})
+}
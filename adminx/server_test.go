@@ -0,0 +1,141 @@
+package adminx
+
+import (
+	"encoding/json"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "admin_test_total",
+	})
+	reg.MustRegister(counter)
+	counter.Inc()
+
+	c := cron.New()
+	_, err := c.AddFunc("@every 1h", func() {})
+	require.NoError(t, err)
+
+	s := NewServer(":0").
+		Gatherer(reg).
+		AddCron("ranking", c).
+		Mount("/mock", mockModule{})
+	s.registerRoutes()
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+
+		wantCode int
+		wantBody func(t *testing.T, body string)
+	}{
+		{
+			name:     "pprof 首页",
+			method:   http.MethodGet,
+			path:     "/debug/pprof/",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, "goroutine")
+			},
+		},
+		{
+			name:     "pprof profile",
+			method:   http.MethodGet,
+			path:     "/debug/pprof/goroutine?debug=1",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, "goroutine profile")
+			},
+		},
+		{
+			name:     "metrics",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, "admin_test_total 1")
+			},
+		},
+		{
+			name:     "构建信息",
+			method:   http.MethodGet,
+			path:     "/buildinfo",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				var res ginx.Result
+				require.NoError(t, json.Unmarshal([]byte(body), &res))
+				data := res.Data.(map[string]any)
+				assert.True(t, strings.HasPrefix(data["go_version"].(string), "go"))
+			},
+		},
+		{
+			name:     "运行时信息",
+			method:   http.MethodGet,
+			path:     "/runtime",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				var res ginx.Result
+				require.NoError(t, json.Unmarshal([]byte(body), &res))
+				data := res.Data.(map[string]any)
+				assert.Greater(t, data["goroutines"], float64(0))
+				assert.Greater(t, data["heap_alloc"], float64(0))
+			},
+		},
+		{
+			name:     "定时任务",
+			method:   http.MethodGet,
+			path:     "/crons",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				var res struct {
+					Data []CronEntryVO `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(body), &res))
+				require.Len(t, res.Data, 1)
+				assert.Equal(t, "ranking", res.Data[0].Cron)
+				// 没有启动，不会计算下一次执行时间
+				assert.Equal(t, int64(0), res.Data[0].Next)
+			},
+		},
+		{
+			name:     "管理模块",
+			method:   http.MethodPost,
+			path:     "/mock/ping",
+			wantCode: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				assert.Equal(t, "pong", body)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			s.Engine().ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			tc.wantBody(t, recorder.Body.String())
+		})
+	}
+}
+
+type mockModule struct{}
+
+func (mockModule) RegisterRoutes(server *gin.RouterGroup) {
+	server.POST("/ping", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "pong")
+	})
+}
@@ -0,0 +1,35 @@
+package configx
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// formatOf 根据文件后缀判断格式
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	default:
+		return FormatYAML
+	}
+}
+
+func unmarshal(format string, data []byte, dst any) error {
+	switch format {
+	case FormatJSON:
+		return json.Unmarshal(data, dst)
+	case FormatYAML:
+		return yaml.Unmarshal(data, dst)
+	default:
+		return fmt.Errorf("未知的配置格式 %s", format)
+	}
+}
@@ -0,0 +1,116 @@
+package configx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvSource 环境变量，通过 env 标签指定名字
+// 例如 `env:"REDIS_ADDR"`，加上前缀 MYAPP_ 之后读取 MYAPP_REDIS_ADDR
+// 嵌套的结构体会递归处理，切片用逗号分隔
+type EnvSource struct {
+	prefix string
+	lookup func(key string) (string, bool)
+}
+
+func NewEnvSource(prefix string) *EnvSource {
+	return &EnvSource{
+		prefix: prefix,
+		lookup: os.LookupEnv,
+	}
+}
+
+func (e *EnvSource) Apply(ctx context.Context, dst any) error {
+	val := reflect.ValueOf(dst)
+	if val.Kind() != reflect.Pointer || val.Elem().Kind() != reflect.Struct {
+		return errors.New("配置必须是结构体指针")
+	}
+	return e.apply(val.Elem())
+}
+
+func (e *EnvSource) apply(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := val.Field(i)
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			if fv.Kind() == reflect.Struct {
+				if err := e.apply(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		raw, ok := e.lookup(e.prefix + name)
+		if !ok {
+			continue
+		}
+		if err := setValue(fv, raw); err != nil {
+			return fmt.Errorf("环境变量 %s 格式错误 %w", e.prefix+name, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(fv reflect.Value, raw string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", fv.Type())
+		}
+		parts := strings.Split(raw, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		fv.Set(reflect.ValueOf(parts).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("不支持的类型 %s", fv.Type())
+	}
+	return nil
+}
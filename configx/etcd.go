@@ -0,0 +1,51 @@
+package configx
+
+import (
+	"context"
+	"fmt"
+	etcdv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdSource etcd 中的一个 key，默认是 yaml 格式
+type EtcdSource struct {
+	client *etcdv3.Client
+	key    string
+	format string
+}
+
+func NewEtcdSource(client *etcdv3.Client, key string) *EtcdSource {
+	return &EtcdSource{
+		client: client,
+		key:    key,
+		format: FormatYAML,
+	}
+}
+
+func (e *EtcdSource) Format(format string) *EtcdSource {
+	e.format = format
+	return e
+}
+
+func (e *EtcdSource) Apply(ctx context.Context, dst any) error {
+	resp, err := e.client.Get(ctx, e.key)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 {
+		return fmt.Errorf("etcd 中没有配置 %s", e.key)
+	}
+	return unmarshal(e.format, resp.Kvs[0].Value, dst)
+}
+
+func (e *EtcdSource) Watch(ctx context.Context, notify func()) error {
+	ch := e.client.Watch(ctx, e.key)
+	for resp := range ch {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		if len(resp.Events) > 0 {
+			notify()
+		}
+	}
+	return ctx.Err()
+}
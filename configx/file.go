@@ -0,0 +1,91 @@
+package configx
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSource 本地文件，支持 yaml 和 json，根据后缀判断
+// 通过轮询文件的修改时间和大小来监听变更，兼容 k8s ConfigMap 的软链接替换
+type FileSource struct {
+	path     string
+	format   string
+	interval time.Duration
+
+	lock sync.Mutex
+	// 最近一次加载的时候文件的状态，避免 Load 和 Watch 之间的变更被漏掉
+	loaded fileState
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{
+		path:     path,
+		format:   formatOf(path),
+		interval: time.Second,
+	}
+}
+
+// Format 指定格式，例如没有后缀的文件
+func (f *FileSource) Format(format string) *FileSource {
+	f.format = format
+	return f
+}
+
+// Interval 轮询间隔，默认 1 秒
+func (f *FileSource) Interval(interval time.Duration) *FileSource {
+	f.interval = interval
+	return f
+}
+
+func (f *FileSource) Apply(ctx context.Context, dst any) error {
+	state, err := f.stat()
+	if err != nil {
+		return err
+	}
+	f.lock.Lock()
+	f.loaded = state
+	f.lock.Unlock()
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	return unmarshal(f.format, data, dst)
+}
+
+func (f *FileSource) Watch(ctx context.Context, notify func()) error {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			cur, err := f.stat()
+			if err != nil {
+				// 文件暂时不存在，等下一轮
+				continue
+			}
+			f.lock.Lock()
+			changed := cur != f.loaded
+			f.lock.Unlock()
+			if changed {
+				notify()
+			}
+		}
+	}
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (f *FileSource) stat() (fileState, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
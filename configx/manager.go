@@ -0,0 +1,131 @@
+package configx
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"reflect"
+	"sync"
+)
+
+// Manager 管理一个配置结构体 T
+// Load 之后可以通过 Get 读取，Watch 之后配置变更会通知订阅者
+type Manager[T any] struct {
+	sources []Source
+	l       accesslog.Logger
+
+	lock sync.RWMutex
+	val  T
+	// 至少成功加载过一次，在这之前 val 是零值
+	loaded bool
+	// 保证通知的顺序和加载的顺序一致
+	reloadLock sync.Mutex
+	// first 代表第一次加载成功
+	listeners []func(old, new T, first bool)
+}
+
+func NewManager[T any](l accesslog.Logger, sources ...Source) *Manager[T] {
+	return &Manager[T]{
+		sources: sources,
+		l:       l,
+	}
+}
+
+// Get 当前的配置
+// 不要修改返回值中的切片和 map
+func (m *Manager[T]) Get() T {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.val
+}
+
+// Load 从所有来源加载配置，配置有变更就通知订阅者
+// 某个来源失败的时候，其余的来源还是会加载，记录各自的状态，返回所有的错误
+func (m *Manager[T]) Load(ctx context.Context) error {
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+	var val T
+	var errs []error
+	for _, s := range m.sources {
+		if err := s.Apply(ctx, &val); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if v, ok := any(&val).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	m.lock.Lock()
+	old := m.val
+	m.val = val
+	first := !m.loaded
+	m.loaded = true
+	listeners := m.listeners
+	m.lock.Unlock()
+	if !first && reflect.DeepEqual(old, val) {
+		return nil
+	}
+	for _, fn := range listeners {
+		fn(old, val, first)
+	}
+	return nil
+}
+
+// OnChange 配置变更的时候回调，在 Load 或者 Watch 的 goroutine 里面执行
+func (m *Manager[T]) OnChange(fn func(old, new T)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.listeners = append(m.listeners, func(old, new T, first bool) {
+		if reflect.DeepEqual(old, new) {
+			return
+		}
+		fn(old, new)
+	})
+}
+
+// Watch 监听所有实现了 Watcher 的来源，不会阻塞
+// 变更之后重新加载所有来源，加载失败的时候保留旧的配置
+// ctx 被取消之后停止监听
+func (m *Manager[T]) Watch(ctx context.Context) {
+	for _, s := range m.sources {
+		w, ok := s.(Watcher)
+		if !ok {
+			continue
+		}
+		go func() {
+			err := w.Watch(ctx, func() {
+				if err := m.Load(ctx); err != nil {
+					m.l.Error("重新加载配置失败", accesslog.Error(err))
+					return
+				}
+				m.l.Info("重新加载配置")
+			})
+			if err != nil && ctx.Err() == nil {
+				m.l.Error("监听配置失败", accesslog.Error(err))
+			}
+		}()
+	}
+}
+
+// Subscribe 订阅配置的一部分，selector 选出来的值变了才会回调
+// 已经加载过的时候，订阅时先用当前的值回调一次，方便初始化
+// 还没有加载的时候，等到第一次 Load 成功之后再回调，不会收到零值的配置
+func Subscribe[T any, V any](m *Manager[T], selector func(T) V, fn func(V)) {
+	m.lock.Lock()
+	m.listeners = append(m.listeners, func(old, new T, first bool) {
+		ov, nv := selector(old), selector(new)
+		if !first && reflect.DeepEqual(ov, nv) {
+			return
+		}
+		fn(nv)
+	})
+	loaded, val := m.loaded, m.val
+	m.lock.Unlock()
+	if loaded {
+		fn(selector(val))
+	}
+}
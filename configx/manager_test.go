@@ -0,0 +1,221 @@
+package configx
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Redis struct {
+		Addr string `yaml:"addr" json:"addr" env:"REDIS_ADDR"`
+	} `yaml:"redis" json:"redis"`
+	Timeout time.Duration `yaml:"timeout" json:"timeout" env:"TIMEOUT"`
+	Debug   bool          `yaml:"debug" json:"debug" env:"DEBUG"`
+	Hosts   []string      `yaml:"hosts" json:"hosts" env:"HOSTS"`
+	Log     struct {
+		AllowReqBody bool `yaml:"allowReqBody" json:"allowReqBody"`
+	} `yaml:"log" json:"log"`
+}
+
+func (c *testConfig) Validate() error {
+	if c.Redis.Addr == "" {
+		return errors.New("redis 地址不能为空")
+	}
+	return nil
+}
+
+func TestManager_Load(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+
+		wantCfg testConfig
+		wantErr string
+	}{
+		{
+			name:    "yaml",
+			file:    "app.yaml",
+			content: "redis:\n  addr: localhost:6379\ntimeout: 3s\nhosts: [a, b]\n",
+			wantCfg: func() testConfig {
+				var cfg testConfig
+				cfg.Redis.Addr = "localhost:6379"
+				cfg.Timeout = 3 * time.Second
+				cfg.Hosts = []string{"a", "b"}
+				return cfg
+			}(),
+		},
+		{
+			name:    "json",
+			file:    "app.json",
+			content: `{"redis":{"addr":"localhost:6379"},"debug":true}`,
+			wantCfg: func() testConfig {
+				var cfg testConfig
+				cfg.Redis.Addr = "localhost:6379"
+				cfg.Debug = true
+				return cfg
+			}(),
+		},
+		{
+			name:    "环境变量覆盖文件",
+			file:    "app.yaml",
+			content: "redis:\n  addr: localhost:6379\ntimeout: 3s\n",
+			env: map[string]string{
+				"APP_REDIS_ADDR": "redis:6379",
+				"APP_TIMEOUT":    "5s",
+				"APP_HOSTS":      "a, b,c",
+			},
+			wantCfg: func() testConfig {
+				var cfg testConfig
+				cfg.Redis.Addr = "redis:6379"
+				cfg.Timeout = 5 * time.Second
+				cfg.Hosts = []string{"a", "b", "c"}
+				return cfg
+			}(),
+		},
+		{
+			name:    "环境变量格式错误",
+			file:    "app.yaml",
+			content: "redis:\n  addr: localhost:6379\n",
+			env:     map[string]string{"APP_DEBUG": "abc"},
+			wantErr: "环境变量 APP_DEBUG 格式错误",
+		},
+		{
+			name:    "校验失败",
+			file:    "app.yaml",
+			content: "timeout: 3s\n",
+			wantErr: "redis 地址不能为空",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+			for key, val := range tc.env {
+				t.Setenv(key, val)
+			}
+			m := NewManager[testConfig](accesslog.NewNopLogger(),
+				NewFileSource(path), NewEnvSource("APP_"))
+			err := m.Load(context.Background())
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCfg, m.Get())
+		})
+	}
+}
+
+func TestManager_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("redis:\n  addr: localhost:6379\nlog:\n  allowReqBody: false\n")
+	m := NewManager[testConfig](accesslog.NewNopLogger(),
+		NewFileSource(path).Interval(10*time.Millisecond))
+	require.NoError(t, m.Load(context.Background()))
+
+	var lock sync.Mutex
+	var got []bool
+	Subscribe(m, func(cfg testConfig) bool {
+		return cfg.Log.AllowReqBody
+	}, func(allow bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		got = append(got, allow)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Watch(ctx)
+
+	// 订阅的部分没有变化，不会回调
+	write("redis:\n  addr: redis:6379\nlog:\n  allowReqBody: false\n")
+	assert.Eventually(t, func() bool {
+		return m.Get().Redis.Addr == "redis:6379"
+	}, time.Second, 10*time.Millisecond)
+
+	// 校验失败，保留旧的配置
+	write("log:\n  allowReqBody: true\n")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "redis:6379", m.Get().Redis.Addr)
+
+	write("redis:\n  addr: redis:6379\nlog:\n  allowReqBody: true\n")
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(got) == 2
+	}, time.Second, 10*time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	// 第一次是订阅的时候的初始值
+	assert.Equal(t, []bool{false, true}, got)
+}
+
+// TestManager_WatchAfterFailedLoad 前面的来源失败了，文件的状态也要记录下来
+// 否则文件没有变化，每一轮轮询都会重新加载并且打印错误
+func TestManager_WatchAfterFailedLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("redis:\n  addr: localhost:6379\n"), 0644))
+	l := logtest.NewRecorder()
+	m := NewManager[testConfig](l, failedSource{}, NewFileSource(path).Interval(10*time.Millisecond))
+	require.Error(t, m.Load(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Watch(ctx)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, l.Len())
+}
+
+type failedSource struct{}
+
+func (failedSource) Apply(ctx context.Context, dst any) error {
+	return errors.New("mock error")
+}
+
+// TestSubscribe_BeforeLoad 加载之前订阅，不会收到零值的配置
+func TestSubscribe_BeforeLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("redis:\n  addr: localhost:6379\n"), 0644))
+	m := NewManager[testConfig](accesslog.NewNopLogger(), NewFileSource(path))
+	var got []string
+	Subscribe(m, func(cfg testConfig) string {
+		return cfg.Redis.Addr
+	}, func(addr string) {
+		got = append(got, addr)
+	})
+	// 第一次加载之前的配置都是零值
+	var debug []bool
+	Subscribe(m, func(cfg testConfig) bool {
+		return cfg.Debug
+	}, func(val bool) {
+		debug = append(debug, val)
+	})
+	assert.Empty(t, got)
+	assert.Empty(t, debug)
+
+	require.NoError(t, m.Load(context.Background()))
+	assert.Equal(t, []string{"localhost:6379"}, got)
+	// 选出来的值和零值一样，第一次加载也要回调
+	assert.Equal(t, []bool{false}, debug)
+
+	// 没有变化不会回调
+	require.NoError(t, m.Load(context.Background()))
+	assert.Equal(t, []string{"localhost:6379"}, got)
+	assert.Equal(t, []bool{false}, debug)
+}
@@ -0,0 +1,22 @@
+package configx
+
+import "context"
+
+// Source 配置来源
+// 多个 Source 按照顺序依次写入同一个结构体，后面的覆盖前面的
+type Source interface {
+	// Apply 把配置写入 dst，dst 是结构体指针
+	Apply(ctx context.Context, dst any) error
+}
+
+// Watcher 可以监听变更的 Source
+type Watcher interface {
+	// Watch 阻塞直到 ctx 被取消，配置变更的时候调用 notify
+	Watch(ctx context.Context, notify func()) error
+}
+
+// Validator 配置结构体实现这个接口，就会在加载之后校验
+// 校验失败的配置不会生效
+type Validator interface {
+	Validate() error
+}
@@ -1,15 +1,249 @@
 package customserver
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/adminx"
 	"github.com/dadaxiaoxiao/go-pkg/ginx"
 	"github.com/dadaxiaoxiao/go-pkg/grpcx"
+	"github.com/dadaxiaoxiao/go-pkg/healthx"
 	"github.com/dadaxiaoxiao/go-pkg/saramax"
 	"github.com/robfig/cron/v3"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type App struct {
 	GRPCServer *grpcx.Server
 	GinServer  *ginx.Server
 	Consumers  []saramax.Consumer
 	Crons      []*cron.Cron
+	// Admin 管理端口，最后关闭，方便在退出过程中继续观察
+	Admin *adminx.Server
+
+	// ShutdownTimeout 优雅退出的超时时间，默认 30 秒
+	ShutdownTimeout time.Duration
+	// Log 为 nil 的时候不打印日志
+	Log accesslog.Logger
+	// Hooks 启动和退出的钩子，按照依赖顺序执行
+	Hooks []Hook
+	// Health 不为 nil 的时候，Run 会把各个组件的检查加入就绪检查
+	// 并且在 gin 和 Admin 上暴露 /healthz 和 /readyz，在 gRPC 上暴露 grpc.health.v1
+	// redis 和 gorm 之类的依赖由调用方自己加入
+	Health *healthx.Registry
+}
+
+// Run 启动所有组件，阻塞直到 ctx 被取消、收到 SIGINT/SIGTERM 或者 server 异常退出
+// 启动顺序：
+// 1. 按照依赖顺序执行钩子的 OnStart
+// 2. 消费者和定时任务
+// 3. 管理端口，gin 和 gRPC，gRPC 启动之后会注册到 etcd
+// 任意一步失败，已经启动的组件和钩子都会按照相反的顺序退出
+// 之后按照顺序优雅退出：
+// 1. gRPC 从注册中心下线，等待处理中的请求结束
+// 2. gin 不再接收新的请求，等待处理中的请求结束
+// 3. 定时任务停止调度，等待运行中的任务结束
+// 4. 消费者退出消费循环
+// 5. 关闭管理端口
+// 6. 按照相反的顺序执行钩子的 OnStop
+// 返回启动、运行和退出过程中的所有错误
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	l := a.logger()
+	hooks, err := sortHooks(a.Hooks)
+	if err != nil {
+		return err
+	}
+	a.registerHealth()
+
+	startedHooks, err := a.startHooks(ctx, hooks)
+	if err != nil {
+		return errors.Join(err, a.rollback(startedHooks, nil))
+	}
+
+	// 消费者和定时任务先启动，都不会阻塞
+	started := make([]saramax.Consumer, 0, len(a.Consumers))
+	for _, c := range a.Consumers {
+		if err := c.Start(); err != nil {
+			err = fmt.Errorf("启动消费者失败 %w", err)
+			return errors.Join(err, a.rollback(startedHooks, started))
+		}
+		started = append(started, c)
+	}
+	for _, c := range a.Crons {
+		c.Start()
+	}
+
+	// server 会阻塞，单独开 goroutine
+	errCh := make(chan error, 3)
+	if a.Admin != nil {
+		go func() {
+			err := a.Admin.Start()
+			if err != nil {
+				err = fmt.Errorf("admin server 退出 %w", err)
+			}
+			errCh <- err
+		}()
+	}
+	if a.GinServer != nil {
+		go func() {
+			err := a.GinServer.Start()
+			if err != nil {
+				err = fmt.Errorf("gin server 退出 %w", err)
+			}
+			errCh <- err
+		}()
+	}
+	if a.GRPCServer != nil {
+		go func() {
+			err := a.GRPCServer.Serve()
+			if err != nil {
+				err = fmt.Errorf("gRPC server 退出 %w", err)
+			}
+			errCh <- err
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		l.Info("收到退出信号，开始优雅退出")
+	case runErr = <-errCh:
+		// 任意一个 server 退出，整个应用都退出
+		l.Error("server 异常退出，开始优雅退出", accesslog.Error(runErr))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout())
+	defer cancel()
+	err = errors.Join(runErr,
+		a.shutdown(shutdownCtx, started),
+		a.stopHooks(shutdownCtx, startedHooks))
+	if err != nil {
+		l.Error("退出应用", accesslog.Error(err))
+	} else {
+		l.Info("退出应用")
+	}
+	return err
+}
+
+// registerHealth 把组件的检查加入就绪检查，必须在 server 启动之前调用
+func (a *App) registerHealth() {
+	if a.Health == nil {
+		return
+	}
+	if a.GRPCServer != nil {
+		a.Health.AddReadiness("grpc", healthx.Component(a.GRPCServer))
+		a.Health.RegisterGRPC(a.GRPCServer.Server)
+	}
+	if a.GinServer != nil {
+		a.Health.AddReadiness("gin", healthx.Component(a.GinServer))
+		a.Health.RegisterRoutes(a.GinServer.Engine)
+	}
+	if a.Admin != nil {
+		// 探针也可以配置到管理端口上
+		a.Health.RegisterRoutes(a.Admin.Engine())
+	}
+	for i, c := range a.Consumers {
+		hc, ok := c.(healthx.HealthChecker)
+		if !ok {
+			continue
+		}
+		a.Health.AddReadiness(fmt.Sprintf("consumer-%d", i), healthx.Component(hc))
+	}
+}
+
+// rollback 启动失败的时候，关闭已经启动的消费者和钩子
+func (a *App) rollback(hooks []Hook, consumers []saramax.Consumer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout())
+	defer cancel()
+	return errors.Join(a.stopConsumers(consumers), a.stopHooks(ctx, hooks))
+}
+
+// shutdown 按照顺序关闭组件
+func (a *App) shutdown(ctx context.Context, consumers []saramax.Consumer) error {
+	var errs []error
+	if a.GRPCServer != nil {
+		errs = append(errs, a.stopGRPC(ctx))
+	}
+	if a.GinServer != nil {
+		if err := a.GinServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("关闭 gin server 失败 %w", err))
+		}
+	}
+	errs = append(errs, a.stopCrons(ctx))
+	errs = append(errs, a.stopConsumers(consumers))
+	if a.Admin != nil {
+		if err := a.Admin.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("关闭 admin server 失败 %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopGRPC 下线并且等待处理中的请求结束，超时就强制关闭
+func (a *App) stopGRPC(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- a.GRPCServer.Close()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("关闭 gRPC server 失败 %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		a.GRPCServer.Stop()
+		return fmt.Errorf("关闭 gRPC server 超时 %w", ctx.Err())
+	}
+}
+
+// stopCrons 停止调度，等待运行中的任务结束
+func (a *App) stopCrons(ctx context.Context) error {
+	dones := make([]context.Context, 0, len(a.Crons))
+	for _, c := range a.Crons {
+		dones = append(dones, c.Stop())
+	}
+	for _, done := range dones {
+		select {
+		case <-done.Done():
+		case <-ctx.Done():
+			return fmt.Errorf("等待定时任务结束超时 %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+func (a *App) stopConsumers(consumers []saramax.Consumer) error {
+	var errs []error
+	for _, c := range consumers {
+		closer, ok := c.(saramax.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭消费者失败 %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *App) shutdownTimeout() time.Duration {
+	if a.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return a.ShutdownTimeout
+}
+
+func (a *App) logger() accesslog.Logger {
+	if a.Log == nil {
+		return accesslog.NewNopLogger()
+	}
+	return a.Log
 }
@@ -0,0 +1,117 @@
+package customserver
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/dadaxiaoxiao/go-pkg/saramax"
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"testing"
+	"time"
+)
+
+func TestApp_Run(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 定时任务运行中的时候退出，要等任务结束
+	jobRunning := make(chan struct{})
+	jobDone := atomic.NewBool(false)
+	c := cron.New(cron.WithSeconds())
+	_, err := c.AddFunc("* * * * * *", func() {
+		select {
+		case jobRunning <- struct{}{}:
+		default:
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		jobDone.Store(true)
+	})
+	require.NoError(t, err)
+	consumer := &mockConsumer{}
+
+	app := &App{
+		GinServer: &ginx.Server{Engine: gin.New(), Addr: "127.0.0.1:0"},
+		Consumers: []saramax.Consumer{consumer},
+		Crons:     []*cron.Cron{c},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-jobRunning
+		cancel()
+	}()
+	err = app.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, consumer.started)
+	assert.True(t, consumer.closed)
+	assert.True(t, jobDone.Load())
+}
+
+func TestApp_Run_Failed(t *testing.T) {
+	testCases := []struct {
+		name      string
+		app       func(consumers ...saramax.Consumer) *App
+		consumers []*mockConsumer
+
+		wantErr    string
+		wantClosed []bool
+	}{
+		{
+			name: "消费者启动失败，关闭已经启动的消费者",
+			app: func(consumers ...saramax.Consumer) *App {
+				return &App{Consumers: consumers}
+			},
+			consumers: []*mockConsumer{{}, {startErr: errors.New("mock error")}, {}},
+			wantErr:   "启动消费者失败 mock error",
+			// 第二个启动失败了，第三个没有启动
+			wantClosed: []bool{true, false, false},
+		},
+		{
+			name: "gin server 启动失败",
+			app: func(consumers ...saramax.Consumer) *App {
+				return &App{
+					GinServer: &ginx.Server{Engine: gin.New(), Addr: "127.0.0.1:-1"},
+					Consumers: consumers,
+				}
+			},
+			consumers:  []*mockConsumer{{}},
+			wantErr:    "gin server 退出 listen tcp: address -1: invalid port",
+			wantClosed: []bool{true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			consumers := make([]saramax.Consumer, 0, len(tc.consumers))
+			for _, c := range tc.consumers {
+				consumers = append(consumers, c)
+			}
+			err := tc.app(consumers...).Run(context.Background())
+			require.Error(t, err)
+			assert.Equal(t, tc.wantErr, err.Error())
+			for i, c := range tc.consumers {
+				assert.Equal(t, tc.wantClosed[i], c.closed)
+			}
+		})
+	}
+}
+
+type mockConsumer struct {
+	startErr error
+	started  bool
+	closed   bool
+}
+
+func (m *mockConsumer) Start() error {
+	if m.startErr != nil {
+		return m.startErr
+	}
+	m.started = true
+	return nil
+}
+
+func (m *mockConsumer) Close() error {
+	m.closed = true
+	return nil
+}
@@ -0,0 +1,131 @@
+package customserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultHookTimeout = 30 * time.Second
+
+// Hook 启动和退出的钩子，例如数据库迁移、预热缓存
+// 所有钩子都在组件启动之前执行，也就是在 gRPC server 注册到 etcd 之前
+type Hook struct {
+	// Name 唯一
+	Name string
+	// DependsOn 依赖的钩子，依赖的钩子先启动，后退出
+	DependsOn []string
+	// Timeout OnStart 和 OnStop 各自的超时时间，默认 30 秒
+	Timeout time.Duration
+	// OnStart 返回 error 的时候，已经启动的钩子会按照相反的顺序执行 OnStop
+	OnStart func(ctx context.Context) error
+	// OnStop 在所有组件退出之后执行
+	OnStop func(ctx context.Context) error
+}
+
+func (h Hook) timeout() time.Duration {
+	if h.Timeout <= 0 {
+		return defaultHookTimeout
+	}
+	return h.Timeout
+}
+
+// sortHooks 按照依赖排序，没有依赖关系的钩子保持注册的顺序
+func sortHooks(hooks []Hook) ([]Hook, error) {
+	index := make(map[string]int, len(hooks))
+	for i, h := range hooks {
+		if _, ok := index[h.Name]; ok {
+			return nil, fmt.Errorf("钩子 %s 重复", h.Name)
+		}
+		index[h.Name] = i
+	}
+	for _, h := range hooks {
+		for _, dep := range h.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("钩子 %s 依赖的 %s 不存在", h.Name, dep)
+			}
+		}
+	}
+
+	res := make([]Hook, 0, len(hooks))
+	done := make([]bool, len(hooks))
+	for len(res) < len(hooks) {
+		progress := false
+		for i, h := range hooks {
+			if done[i] || !depsDone(h, index, done) {
+				continue
+			}
+			done[i] = true
+			res = append(res, h)
+			progress = true
+			// 每次都从头开始找，保持注册的顺序
+			break
+		}
+		if !progress {
+			var names []string
+			for i, h := range hooks {
+				if !done[i] {
+					names = append(names, h.Name)
+				}
+			}
+			return nil, fmt.Errorf("钩子存在循环依赖 %s", strings.Join(names, ", "))
+		}
+	}
+	return res, nil
+}
+
+func depsDone(h Hook, index map[string]int, done []bool) bool {
+	for _, dep := range h.DependsOn {
+		if !done[index[dep]] {
+			return false
+		}
+	}
+	return true
+}
+
+// startHooks 按照顺序执行 OnStart，返回已经启动成功的钩子
+func (a *App) startHooks(ctx context.Context, hooks []Hook) ([]Hook, error) {
+	started := make([]Hook, 0, len(hooks))
+	for _, h := range hooks {
+		if h.OnStart != nil {
+			if err := runHook(ctx, h, h.OnStart); err != nil {
+				return started, fmt.Errorf("执行钩子 %s 失败 %w", h.Name, err)
+			}
+		}
+		started = append(started, h)
+	}
+	return started, nil
+}
+
+// stopHooks 按照相反的顺序执行 OnStop，某个钩子失败不影响其它钩子
+func (a *App) stopHooks(ctx context.Context, hooks []Hook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.OnStop == nil {
+			continue
+		}
+		if err := runHook(ctx, h, h.OnStop); err != nil {
+			errs = append(errs, fmt.Errorf("退出钩子 %s 失败 %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runHook(ctx context.Context, h Hook, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// 钩子没有响应 ctx，不再等待
+		return ctx.Err()
+	}
+}
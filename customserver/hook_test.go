@@ -0,0 +1,206 @@
+package customserver
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/saramax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSortHooks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		hooks []Hook
+
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name: "没有依赖，保持注册顺序",
+			hooks: []Hook{
+				{Name: "a"}, {Name: "b"}, {Name: "c"},
+			},
+			wantNames: []string{"a", "b", "c"},
+		},
+		{
+			name: "按照依赖排序",
+			hooks: []Hook{
+				{Name: "consumer", DependsOn: []string{"cache", "migrate"}},
+				{Name: "cache", DependsOn: []string{"migrate"}},
+				{Name: "migrate"},
+				{Name: "other"},
+			},
+			wantNames: []string{"migrate", "cache", "consumer", "other"},
+		},
+		{
+			name: "重复",
+			hooks: []Hook{
+				{Name: "a"}, {Name: "a"},
+			},
+			wantErr: "钩子 a 重复",
+		},
+		{
+			name: "依赖不存在",
+			hooks: []Hook{
+				{Name: "a", DependsOn: []string{"b"}},
+			},
+			wantErr: "钩子 a 依赖的 b 不存在",
+		},
+		{
+			name: "循环依赖",
+			hooks: []Hook{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c"},
+			},
+			wantErr: "钩子存在循环依赖 a, b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hooks, err := sortHooks(tc.hooks)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, len(hooks))
+			for _, h := range hooks {
+				names = append(names, h.Name)
+			}
+			assert.Equal(t, tc.wantNames, names)
+		})
+	}
+}
+
+func TestApp_Run_Hooks(t *testing.T) {
+	testCases := []struct {
+		name      string
+		hooks     func(r *hookRecorder) []Hook
+		consumers []*mockConsumer
+		cancel    bool
+
+		wantErr    string
+		wantEvents []string
+		wantClosed []bool
+	}{
+		{
+			name: "正常启动和退出",
+			hooks: func(r *hookRecorder) []Hook {
+				return []Hook{
+					r.hook("cache", nil, "migrate"),
+					r.hook("migrate", nil),
+				}
+			},
+			consumers: []*mockConsumer{{}},
+			cancel:    true,
+			wantEvents: []string{
+				"start migrate", "start cache",
+				"stop cache", "stop migrate",
+			},
+			wantClosed: []bool{true},
+		},
+		{
+			name: "钩子失败，回滚已经启动的钩子",
+			hooks: func(r *hookRecorder) []Hook {
+				return []Hook{
+					r.hook("migrate", nil),
+					r.hook("cache", errors.New("mock error"), "migrate"),
+					r.hook("other", nil, "cache"),
+				}
+			},
+			consumers:  []*mockConsumer{{}},
+			wantErr:    "执行钩子 cache 失败 mock error",
+			wantEvents: []string{"start migrate", "start cache", "stop migrate"},
+			// 消费者没有启动
+			wantClosed: []bool{false},
+		},
+		{
+			name: "钩子超时",
+			hooks: func(r *hookRecorder) []Hook {
+				h := r.hook("slow", nil)
+				h.Timeout = 10 * time.Millisecond
+				h.OnStart = func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return []Hook{r.hook("migrate", nil), h}
+			},
+			wantErr:    "执行钩子 slow 失败 context deadline exceeded",
+			wantEvents: []string{"start migrate", "stop migrate"},
+		},
+		{
+			name: "消费者启动失败，回滚钩子",
+			hooks: func(r *hookRecorder) []Hook {
+				return []Hook{r.hook("migrate", nil)}
+			},
+			consumers:  []*mockConsumer{{}, {startErr: errors.New("mock error")}},
+			wantErr:    "启动消费者失败 mock error",
+			wantEvents: []string{"start migrate", "stop migrate"},
+			wantClosed: []bool{true, false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &hookRecorder{}
+			consumers := make([]saramax.Consumer, 0, len(tc.consumers))
+			for _, c := range tc.consumers {
+				consumers = append(consumers, c)
+			}
+			app := &App{
+				Hooks:     tc.hooks(r),
+				Consumers: consumers,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				// 启动完成之后退出
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+			err := app.Run(ctx)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.wantErr, err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantEvents, r.events)
+			for i, c := range tc.consumers {
+				assert.Equal(t, tc.wantClosed[i], c.closed)
+			}
+		})
+	}
+}
+
+type hookRecorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *hookRecorder) record(event string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *hookRecorder) hook(name string, startErr error, deps ...string) Hook {
+	return Hook{
+		Name:      name,
+		DependsOn: deps,
+		OnStart: func(ctx context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,158 @@
+package ginx
+
+import (
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrBadRequest 参数绑定失败
+var ErrBadRequest = RegisterError(ReservedCodeMin+http.StatusBadRequest, "参数错误",
+	http.StatusBadRequest, accesslog.WarnLevel)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 优先使用 json, form, uri, header 标签里面的名字
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+// BindFailedHandler 参数绑定失败的时候，决定响应的状态码和 Result
+// 日志和指标由 Wrap 系列方法统一处理
+type BindFailedHandler func(ctx *gin.Context, err error) (int, Result)
+
+// BindFailed 包变量，可以替换成自己的实现
+var BindFailed BindFailedHandler = DefaultBindFailed
+
+// DefaultBindFailed 校验失败的时候，按照 Accept-Language 翻译成中文或者英文，放在 Result.Data 里面
+// 翻译需要在启动的时候调用 InitValidator
+// 其它错误，例如 JSON 格式错误，只返回 ErrBadRequest
+func DefaultBindFailed(ctx *gin.Context, err error) (int, Result) {
+	res := Result{
+		Code: ErrBadRequest.Code,
+		Msg:  ErrBadRequest.Msg,
+	}
+	var ves validator.ValidationErrors
+	if errors.As(err, &ves) {
+		res.Data = TranslateValidationErrors(ves, ctx.GetHeader("Accept-Language"))
+	}
+	return ErrBadRequest.HTTPStatus, res
+}
+
+// TranslateValidationErrors 把校验错误翻译成 acceptLanguage 对应的语言，默认中文
+func TranslateValidationErrors(ves validator.ValidationErrors, acceptLanguage string) []FieldError {
+	trans := findTranslator(acceptLanguage)
+	res := make([]FieldError, 0, len(ves))
+	for _, fe := range ves {
+		res = append(res, FieldError{
+			Field: fe.Field(),
+			Msg:   fe.Translate(trans),
+		})
+	}
+	return res
+}
+
+var (
+	zhLocale = zh.New()
+	// uni 只在 InitValidator 里面注册翻译，这里可以直接初始化
+	uni      = ut.New(zhLocale, zhLocale, en.New())
+	tagNames = []string{"json", "form", "uri", "header"}
+)
+
+// InitValidator 在 gin 的校验器(binding.Validator)上注册中英文翻译
+// 并且让校验错误使用 json, form, uri, header 标签里面的名字作为字段名
+// 会影响整个应用里面 binding.Validator 的行为，而且校验器在校验的时候注册不是并发安全的
+// 所以要在启动的时候，处理请求之前调用
+// 没有调用的时候，字段名是结构体的字段名，错误信息是 validator 原始的英文信息
+func InitValidator() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("ginx: binding.Validator 不是 go-playground/validator")
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range tagNames {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	zhTrans, _ := uni.GetTranslator("zh")
+	if err := zhTranslations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		return err
+	}
+	enTrans, _ := uni.GetTranslator("en")
+	return enTranslations.RegisterDefaultTranslations(v, enTrans)
+}
+
+// findTranslator 解析 Accept-Language，例如 en-US,en;q=0.9,zh-CN;q=0.8
+// 不考虑 q 的权重，按照出现的顺序查找
+func findTranslator(acceptLanguage string) ut.Translator {
+	var locales []string
+	for _, lang := range strings.Split(acceptLanguage, ",") {
+		lang, _, _ = strings.Cut(strings.TrimSpace(lang), ";")
+		if lang == "" {
+			continue
+		}
+		base, _, _ := strings.Cut(lang, "-")
+		locales = append(locales, strings.ReplaceAll(lang, "-", "_"), base)
+	}
+	trans, _ := uni.FindTranslator(locales...)
+	return trans
+}
+
+// shouldBindRequest 依次从请求头、查询参数、请求体和路径参数绑定到同一个结构体
+// 后面的会覆盖前面的，路径参数放在最后，避免请求体里面的 id 覆盖路径里面的 id
+// 单个来源绑定的时候，其它来源的字段还没有赋值，所以忽略中间的校验错误，最后统一校验
+func shouldBindRequest(ctx *gin.Context, obj any) error {
+	binds := []func(obj any) error{
+		ctx.ShouldBindHeader,
+		ctx.ShouldBindQuery,
+	}
+	// 没有请求体的时候不需要绑定，ContentLength 为 -1 代表长度未知
+	if ctx.Request.ContentLength != 0 {
+		binds = append(binds, func(obj any) error {
+			return ctx.ShouldBindWith(obj, binding.Default(ctx.Request.Method, ctx.ContentType()))
+		})
+	}
+	binds = append(binds, ctx.ShouldBindUri)
+	for _, bind := range binds {
+		err := bind(obj)
+		var ves validator.ValidationErrors
+		if err != nil && !errors.As(err, &ves) {
+			return err
+		}
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// bindFailed 参数绑定失败，打印 warn 日志，记录指标，然后响应
+func bindFailed(ctx *gin.Context, l accesslog.Logger, err error, write func(code int, obj any)) {
+	_ = ctx.Error(err).SetType(gin.ErrorTypeBind)
+	l.WithContext(ctx.Request.Context()).Warn("参数绑定失败",
+		accesslog.String("path", ctx.Request.URL.Path),
+		accesslog.String("route", ctx.FullPath()),
+		accesslog.Error(err))
+	status, res := BindFailed(ctx, err)
+	vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
+	write(status, res)
+	ctx.Abort()
+}
@@ -0,0 +1,231 @@
+package ginx
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type signUpReq struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+func TestWrapBodyV1_BindFailed(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		acceptLanguage string
+		bindFailed     BindFailedHandler
+
+		wantCode   int
+		wantResult Result
+	}{
+		{
+			name:     "成功",
+			body:     `{"email":"abc@qq.com","password":"123456"}`,
+			wantCode: http.StatusOK,
+			wantResult: Result{
+				Msg: "OK",
+			},
+		},
+		{
+			name:     "校验失败，默认中文",
+			body:     `{"email":"abc","password":"123"}`,
+			wantCode: http.StatusBadRequest,
+			wantResult: Result{
+				Code: ErrBadRequest.Code,
+				Msg:  "参数错误",
+				Data: []any{
+					map[string]any{"field": "email", "msg": "email必须是一个有效的邮箱"},
+					map[string]any{"field": "password", "msg": "password长度必须至少为6个字符"},
+				},
+			},
+		},
+		{
+			name:           "校验失败，英文",
+			body:           `{"email":"abc@qq.com"}`,
+			acceptLanguage: "en-US,en;q=0.9,zh-CN;q=0.8",
+			wantCode:       http.StatusBadRequest,
+			wantResult: Result{
+				Code: ErrBadRequest.Code,
+				Msg:  "参数错误",
+				Data: []any{
+					map[string]any{"field": "password", "msg": "password is a required field"},
+				},
+			},
+		},
+		{
+			name:     "JSON 格式错误",
+			body:     `{"email":`,
+			wantCode: http.StatusBadRequest,
+			wantResult: Result{
+				Code: ErrBadRequest.Code,
+				Msg:  "参数错误",
+			},
+		},
+		{
+			name: "自定义处理",
+			body: `{"email":"abc"}`,
+			bindFailed: func(ctx *gin.Context, err error) (int, Result) {
+				return http.StatusOK, Result{Code: 400, Msg: "输入有误"}
+			},
+			wantCode: http.StatusOK,
+			wantResult: Result{
+				Code: 400,
+				Msg:  "输入有误",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := logtest.NewRecorder()
+			L = l
+			if tc.bindFailed != nil {
+				BindFailed = tc.bindFailed
+				defer func() {
+					BindFailed = DefaultBindFailed
+				}()
+			}
+			server := gin.New()
+			server.POST("/users/signup", WrapBodyV1[signUpReq](func(ctx *gin.Context, req signUpReq) (Result, error) {
+				return Result{Msg: "OK"}, nil
+			}))
+
+			req, err := http.NewRequest(http.MethodPost, "/users/signup", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			var res Result
+			err = json.NewDecoder(recorder.Body).Decode(&res)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantResult, res)
+			if tc.wantResult.Msg == "OK" {
+				assert.Equal(t, 0, l.Len())
+				return
+			}
+			l.AssertLogged(t, accesslog.WarnLevel, "参数绑定失败")
+		})
+	}
+}
+
+type getArticleReq struct {
+	Id    int64  `uri:"id" binding:"required"`
+	Biz   string `form:"biz" binding:"required"`
+	Token string `header:"X-Token" binding:"required"`
+	Title string `json:"title"`
+}
+
+func TestWrapRequest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		header map[string]string
+		body   string
+
+		wantCode   int
+		wantResult Result
+	}{
+		{
+			name:   "多个来源",
+			method: http.MethodPost,
+			url:    "/articles/123?biz=article",
+			header: map[string]string{"X-Token": "abc", "Content-Type": "application/json"},
+			body:   `{"title":"hello"}`,
+
+			wantCode: http.StatusOK,
+			wantResult: Result{
+				Data: map[string]any{"Id": float64(123), "Biz": "article", "Token": "abc", "title": "hello"},
+			},
+		},
+		{
+			name:   "请求体和查询参数不能覆盖路径参数",
+			method: http.MethodPut,
+			url:    "/articles/123?biz=article&Id=998",
+			header: map[string]string{"X-Token": "abc", "Content-Type": "application/json"},
+			body:   `{"id":999,"title":"hello"}`,
+
+			wantCode: http.StatusOK,
+			wantResult: Result{
+				Data: map[string]any{"Id": float64(123), "Biz": "article", "Token": "abc", "title": "hello"},
+			},
+		},
+		{
+			name:   "没有请求体",
+			method: http.MethodGet,
+			url:    "/articles/123?biz=article",
+			header: map[string]string{"X-Token": "abc"},
+
+			wantCode: http.StatusOK,
+			wantResult: Result{
+				Data: map[string]any{"Id": float64(123), "Biz": "article", "Token": "abc", "title": ""},
+			},
+		},
+		{
+			name:   "缺少请求头和查询参数",
+			method: http.MethodGet,
+			url:    "/articles/123",
+
+			wantCode: http.StatusBadRequest,
+			wantResult: Result{
+				Code: ErrBadRequest.Code,
+				Msg:  "参数错误",
+				Data: []any{
+					map[string]any{"field": "biz", "msg": "biz为必填字段"},
+					map[string]any{"field": "X-Token", "msg": "X-Token为必填字段"},
+				},
+			},
+		},
+		{
+			name:   "路径参数格式错误",
+			method: http.MethodGet,
+			url:    "/articles/abc?biz=article",
+			header: map[string]string{"X-Token": "abc"},
+
+			wantCode: http.StatusBadRequest,
+			wantResult: Result{
+				Code: ErrBadRequest.Code,
+				Msg:  "参数错误",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			L = logtest.NewRecorder()
+			server := gin.New()
+			server.Handle(tc.method, "/articles/:id", WrapRequest[getArticleReq](func(ctx *gin.Context, req getArticleReq) (Result, error) {
+				return Result{Data: req}, nil
+			}))
+
+			req, err := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			for key, val := range tc.header {
+				req.Header.Set(key, val)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			var res Result
+			err = json.NewDecoder(recorder.Body).Decode(&res)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantResult, res)
+		})
+	}
+}
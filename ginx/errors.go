@@ -0,0 +1,111 @@
+package ginx
+
+import (
+	"fmt"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// 这个范围的错误码留给 ginx 和它的中间件使用，业务注册错误码的时候要避开
+// 按照 ReservedCodeMin + HTTP 状态码 分配，例如 ErrInternal 是 900500
+const (
+	ReservedCodeMin = 900000
+	ReservedCodeMax = 900999
+)
+
+var (
+	// ErrInternal 没有注册的错误都会转换为 ErrInternal，不会把内部错误暴露给用户
+	ErrInternal = RegisterError(ReservedCodeMin+http.StatusInternalServerError, "系统错误",
+		http.StatusInternalServerError, accesslog.ErrorLevel)
+	// ErrTimeout 业务返回 context.DeadlineExceeded 的时候使用
+	ErrTimeout = RegisterError(ReservedCodeMin+http.StatusGatewayTimeout, "请求超时",
+		http.StatusGatewayTimeout, accesslog.WarnLevel)
+)
+
+var (
+	errLock     sync.RWMutex
+	errRegistry = make(map[int]*Error)
+)
+
+// Error 业务错误
+// 在 Wrap 系列方法中，返回的 error 如果是 *Error
+// 会使用 Code, Msg 填充 Result，使用 HTTPStatus 作为状态码，按照 Level 打印日志
+type Error struct {
+	// Code 业务错误码，对应 Result.Code
+	Code int
+	// Msg 给用户看的信息，对应 Result.Msg
+	Msg string
+	// HTTPStatus 响应的状态码
+	HTTPStatus int
+	// Level 日志级别，例如用户不存在这种，不需要打 Error
+	Level accesslog.Level
+	// cause 内部错误，只打印到日志，不返回给用户
+	cause error
+}
+
+// RegisterError 注册错误码，一般在包变量里面调用
+// code 重复会 panic，业务不要使用 [ReservedCodeMin, ReservedCodeMax] 里面的错误码
+func RegisterError(code int, msg string, httpStatus int, level accesslog.Level) *Error {
+	errLock.Lock()
+	defer errLock.Unlock()
+	if old, ok := errRegistry[code]; ok {
+		panic(fmt.Sprintf("ginx: 错误码 %d 重复注册, 已经注册为 %s", code, old.Msg))
+	}
+	e := &Error{
+		Code:       code,
+		Msg:        msg,
+		HTTPStatus: httpStatus,
+		Level:      level,
+	}
+	errRegistry[code] = e
+	return e
+}
+
+// LookupError 根据错误码查找
+func LookupError(code int) (*Error, bool) {
+	errLock.RLock()
+	defer errLock.RUnlock()
+	e, ok := errRegistry[code]
+	return e, ok
+}
+
+// Errors 所有注册的错误，按照错误码排序，可以用来生成错误码文档
+func Errors() []*Error {
+	errLock.RLock()
+	defer errLock.RUnlock()
+	res := make([]*Error, 0, len(errRegistry))
+	for _, e := range errRegistry {
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Code < res[j].Code
+	})
+	return res
+}
+
+func (e *Error) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("code: %d, msg: %s", e.Code, e.Msg)
+	}
+	return fmt.Sprintf("code: %d, msg: %s, cause: %s", e.Code, e.Msg, e.cause)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同就认为是同一个错误
+// 所以 errors.Is(ErrUserNotFound.Wrap(err), ErrUserNotFound) 是 true
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap 附带内部错误，内部错误只会打印到日志
+func (e *Error) Wrap(cause error) *Error {
+	res := *e
+	res.cause = cause
+	return &res
+}
@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StatusHeader 响应头，HIT 表示命中缓存，MISS 表示没有命中
+const StatusHeader = "X-Cache"
+
+// Builder 响应缓存中间件，只缓存 GET 请求 200 并且 Result.Code 是 0 的响应
+// key 由请求路径，排序之后的查询参数和可选的用户标识组成
+// 响应带上 ETag 和 Cache-Control，If-None-Match 匹配的时候返回 304
+// 数据变更之后，业务代码调用 Invalidate 按照 tag 删除缓存
+type Builder struct {
+	store  Store
+	l      accesslog.Logger
+	prefix string
+	ttl    time.Duration
+	// 浏览器缓存的时间，小于 0 的时候返回 no-cache，要求每次都用 ETag 校验
+	maxAge time.Duration
+	// 区分不同的用户，返回空字符串表示所有用户共享
+	scope func(ctx *gin.Context) string
+	tags  func(ctx *gin.Context) []string
+}
+
+func NewBuilder(store Store, l accesslog.Logger) *Builder {
+	return &Builder{
+		store:  store,
+		l:      l,
+		prefix: "cache",
+		ttl:    time.Minute,
+		maxAge: -1,
+		scope: func(ctx *gin.Context) string {
+			return ""
+		},
+		tags: func(ctx *gin.Context) []string {
+			return nil
+		},
+	}
+}
+
+func (b *Builder) Prefix(prefix string) *Builder {
+	b.prefix = prefix
+	return b
+}
+
+// TTL 服务端缓存的时间，默认 1 分钟
+func (b *Builder) TTL(ttl time.Duration) *Builder {
+	b.ttl = ttl
+	return b
+}
+
+// MaxAge Cache-Control 的 max-age，默认不允许浏览器直接使用缓存
+func (b *Builder) MaxAge(maxAge time.Duration) *Builder {
+	b.maxAge = maxAge
+	return b
+}
+
+// Scope 例如返回 JWT 里面的用户 id，这时候 Cache-Control 是 private
+func (b *Builder) Scope(fn func(ctx *gin.Context) string) *Builder {
+	b.scope = fn
+	return b
+}
+
+// Tags 例如文章详情返回 article:123，更新文章之后调用 Invalidate(ctx, "article:123")
+func (b *Builder) Tags(fn func(ctx *gin.Context) []string) *Builder {
+	b.tags = fn
+	return b
+}
+
+// Invalidate 删除带有这些 tag 的缓存
+func (b *Builder) Invalidate(ctx context.Context, tags ...string) error {
+	return b.store.InvalidateTags(ctx, tags...)
+}
+
+// Build 存储出错的时候直接执行业务，不影响业务
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet {
+			return
+		}
+		scope := b.scope(ctx)
+		key := b.key(ctx, scope)
+		l := b.l.WithContext(ctx.Request.Context()).With(accesslog.String("key", key))
+
+		resp, err := b.get(ctx, key)
+		switch {
+		case err == nil:
+			ctx.Header(StatusHeader, "HIT")
+			b.write(ctx, ctx.Writer, resp, scope)
+			ctx.Abort()
+			return
+		case !errors.Is(err, ErrMiss):
+			l.Error("查询响应缓存失败", accesslog.Error(err))
+		}
+
+		// 要在写响应之前算出 ETag，所以响应先写到缓冲区
+		origin := ctx.Writer
+		writer := &responseWriter{ResponseWriter: origin, status: http.StatusOK}
+		ctx.Writer = writer
+		// 业务 panic 的时候，外层的 recovery 要写到原本的 Writer 里面
+		defer func() {
+			ctx.Writer = origin
+		}()
+		ctx.Next()
+
+		if !b.cacheable(ctx, writer) {
+			origin.WriteHeader(writer.status)
+			_, _ = origin.Write(writer.body.Bytes())
+			return
+		}
+		resp = storedResponse{
+			ContentType: writer.Header().Get("Content-Type"),
+			ETag:        etag(writer.body.Bytes()),
+			Body:        writer.body.Bytes(),
+		}
+		ctx.Header(StatusHeader, "MISS")
+		b.write(ctx, origin, resp, scope)
+
+		data, err := json.Marshal(resp)
+		if err != nil {
+			l.Error("序列化响应缓存失败", accesslog.Error(err))
+			return
+		}
+		// 业务可能已经取消了 ctx，这里单独控制超时
+		setCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err = b.store.Set(setCtx, key, data, b.ttl, b.tags(ctx)); err != nil {
+			l.Error("保存响应缓存失败", accesslog.Error(err))
+		}
+	}
+}
+
+// cacheable 只缓存成功的响应
+// ginx.Wrap 在业务填充了 Result 的时候，出错了也会返回 200，所以还要检查 ctx.Errors 和 code
+func (b *Builder) cacheable(ctx *gin.Context, writer *responseWriter) bool {
+	if writer.status != http.StatusOK || len(ctx.Errors) > 0 {
+		return false
+	}
+	if !strings.HasPrefix(writer.Header().Get("Content-Type"), "application/json") {
+		return true
+	}
+	var res struct {
+		Code int `json:"code"`
+	}
+	// 不是 Result 格式的 JSON，例如数组，也可以缓存
+	if err := json.Unmarshal(writer.body.Bytes(), &res); err != nil {
+		return true
+	}
+	return res.Code == 0
+}
+
+// key 查询参数排序之后拼接，参数顺序不同的请求使用同一个缓存
+// 使用真实的请求路径，而不是路由，否则 /articles/1 和 /articles/2 会共享缓存
+func (b *Builder) key(ctx *gin.Context, scope string) string {
+	query := ctx.Request.URL.Query()
+	for _, vals := range query {
+		sort.Strings(vals)
+	}
+	// Encode 会按照参数名排序
+	return fmt.Sprintf("%s:%s:%s:%s", b.prefix, ctx.Request.URL.Path, query.Encode(), scope)
+}
+
+func (b *Builder) get(ctx context.Context, key string) (storedResponse, error) {
+	var resp storedResponse
+	data, err := b.store.Get(ctx, key)
+	if err != nil {
+		return resp, err
+	}
+	err = json.Unmarshal(data, &resp)
+	return resp, err
+}
+
+func (b *Builder) write(ctx *gin.Context, w gin.ResponseWriter, resp storedResponse, scope string) {
+	header := w.Header()
+	header.Set("ETag", resp.ETag)
+	header.Set("Cache-Control", b.cacheControl(scope))
+	if matchETag(ctx.GetHeader("If-None-Match"), resp.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		w.WriteHeaderNow()
+		return
+	}
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp.Body)
+}
+
+func (b *Builder) cacheControl(scope string) string {
+	visibility := "public"
+	if scope != "" {
+		visibility = "private"
+	}
+	if b.maxAge < 0 {
+		return visibility + ", no-cache"
+	}
+	return fmt.Sprintf("%s, max-age=%d", visibility, int64(b.maxAge.Seconds()))
+}
+
+func etag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// matchETag If-None-Match 可能有多个值，也可能是 *，弱校验忽略 W/ 前缀
+func matchETag(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, val := range strings.Split(header, ",") {
+		val = strings.TrimSpace(val)
+		if val == "*" || strings.TrimPrefix(val, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+type storedResponse struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+	Body        []byte `json:"body"`
+}
+
+// responseWriter 缓存响应，业务执行完之后再写出去
+type responseWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (r *responseWriter) WriteHeader(code int) {
+	if code > 0 && !r.written {
+		r.status = code
+	}
+}
+
+func (r *responseWriter) WriteHeaderNow() {
+	r.written = true
+}
+
+func (r *responseWriter) Write(data []byte) (int, error) {
+	r.written = true
+	return r.body.Write(data)
+}
+
+func (r *responseWriter) WriteString(data string) (int, error) {
+	r.written = true
+	return r.body.WriteString(data)
+}
+
+func (r *responseWriter) Status() int {
+	return r.status
+}
+
+func (r *responseWriter) Size() int {
+	if !r.written {
+		return -1
+	}
+	return r.body.Len()
+}
+
+func (r *responseWriter) Written() bool {
+	return r.written
+}
@@ -0,0 +1,270 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/dadaxiaoxiao/go-pkg/ginx/middlerwares/recovery"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	type request struct {
+		url         string
+		uid         string
+		ifNoneMatch bool
+		// 发送请求之前失效的 tag
+		invalidate []string
+	}
+	testCases := []struct {
+		name    string
+		status  int
+		reqs    []request
+		maxAge  time.Duration
+		useUser bool
+
+		wantCodes        []int
+		wantCache        []string
+		wantCalls        int
+		wantCacheControl string
+	}{
+		{
+			name:             "第二次命中缓存",
+			status:           http.StatusOK,
+			reqs:             []request{{url: "/articles/1"}, {url: "/articles/1"}},
+			wantCodes:        []int{http.StatusOK, http.StatusOK},
+			wantCache:        []string{"MISS", "HIT"},
+			wantCalls:        1,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:   "不同的路径参数不共享",
+			status: http.StatusOK,
+			reqs: []request{
+				{url: "/articles/1"},
+				{url: "/articles/2"},
+				{url: "/articles/1"},
+			},
+			wantCodes:        []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantCache:        []string{"MISS", "MISS", "HIT"},
+			wantCalls:        2,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:   "查询参数顺序不同使用同一个缓存",
+			status: http.StatusOK,
+			reqs: []request{
+				{url: "/articles/1?b=2&a=1&a=0"},
+				{url: "/articles/1?a=0&b=2&a=1"},
+				{url: "/articles/1?a=1"},
+			},
+			maxAge:           time.Minute,
+			wantCodes:        []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantCache:        []string{"MISS", "HIT", "MISS"},
+			wantCalls:        2,
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:             "ETag 匹配返回 304",
+			status:           http.StatusOK,
+			reqs:             []request{{url: "/articles/1"}, {url: "/articles/1", ifNoneMatch: true}},
+			wantCodes:        []int{http.StatusOK, http.StatusNotModified},
+			wantCache:        []string{"MISS", "HIT"},
+			wantCalls:        1,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:   "按照 tag 失效",
+			status: http.StatusOK,
+			reqs: []request{
+				{url: "/articles/1"},
+				{url: "/articles/1", invalidate: []string{"article:2"}},
+				{url: "/articles/1", invalidate: []string{"article:1"}},
+			},
+			wantCodes:        []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantCache:        []string{"MISS", "HIT", "MISS"},
+			wantCalls:        2,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:   "不同用户不共享",
+			status: http.StatusOK,
+			reqs: []request{
+				{url: "/articles/1", uid: "1"},
+				{url: "/articles/1", uid: "2"},
+				{url: "/articles/1", uid: "1"},
+			},
+			useUser:          true,
+			wantCodes:        []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantCache:        []string{"MISS", "MISS", "HIT"},
+			wantCalls:        2,
+			wantCacheControl: "private, no-cache",
+		},
+		{
+			name:      "非 200 不缓存",
+			status:    http.StatusNotFound,
+			reqs:      []request{{url: "/articles/1"}, {url: "/articles/1"}},
+			wantCodes: []int{http.StatusNotFound, http.StatusNotFound},
+			wantCache: []string{"", ""},
+			wantCalls: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := NewBuilder(NewLocalStore(10), accesslog.NewNopLogger()).
+				Tags(func(ctx *gin.Context) []string {
+					return []string{"article:" + ctx.Param("id")}
+				})
+			if tc.maxAge > 0 {
+				builder.MaxAge(tc.maxAge)
+			}
+			if tc.useUser {
+				builder.Scope(func(ctx *gin.Context) string {
+					return ctx.GetHeader("uid")
+				})
+			}
+			calls := 0
+			server := gin.New()
+			server.Use(builder.Build())
+			server.GET("/articles/:id", func(ctx *gin.Context) {
+				calls++
+				ctx.JSON(tc.status, gin.H{"id": ctx.Param("id")})
+			})
+
+			var etag string
+			for i, r := range tc.reqs {
+				if len(r.invalidate) > 0 {
+					require.NoError(t, builder.Invalidate(context.Background(), r.invalidate...))
+				}
+				req := httptest.NewRequest(http.MethodGet, r.url, nil)
+				req.Header.Set("uid", r.uid)
+				if r.ifNoneMatch {
+					req.Header.Set("If-None-Match", etag)
+				}
+				recorder := httptest.NewRecorder()
+				server.ServeHTTP(recorder, req)
+
+				assert.Equal(t, tc.wantCodes[i], recorder.Code)
+				assert.Equal(t, tc.wantCache[i], recorder.Header().Get(StatusHeader))
+				if recorder.Code == http.StatusOK {
+					// 响应的是请求的那一篇文章
+					id := strings.TrimPrefix(req.URL.Path, "/articles/")
+					assert.JSONEq(t, `{"id":"`+id+`"}`, recorder.Body.String())
+					assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+				}
+				if recorder.Code == http.StatusNotModified {
+					assert.Empty(t, recorder.Body.String())
+				}
+				if tc.wantCacheControl != "" {
+					assert.Equal(t, tc.wantCacheControl, recorder.Header().Get("Cache-Control"))
+					etag = recorder.Header().Get("ETag")
+					assert.NotEmpty(t, etag)
+				}
+			}
+			assert.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+func TestBuilder_NotCacheable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name    string
+		handler gin.HandlerFunc
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "业务填充了错误码",
+			handler: func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, ginx.Result{Code: 5, Msg: "系统错误"})
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":5,"msg":"系统错误","data":null}`,
+		},
+		{
+			name: "记录了错误",
+			handler: func(ctx *gin.Context) {
+				_ = ctx.Error(errors.New("mock error"))
+				ctx.JSON(http.StatusOK, ginx.Result{Msg: "OK"})
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"OK","data":null}`,
+		},
+		{
+			name: "panic",
+			handler: func(ctx *gin.Context) {
+				panic("mock panic")
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":900500,"msg":"系统错误","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewLocalStore(10)
+			server := gin.New()
+			server.Use(recovery.NewBuilder(accesslog.NewNopLogger()).Build(),
+				NewBuilder(store, accesslog.NewNopLogger()).Build())
+			server.GET("/articles/:id", tc.handler)
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+				recorder := httptest.NewRecorder()
+				server.ServeHTTP(recorder, req)
+				assert.Equal(t, tc.wantCode, recorder.Code)
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+				assert.Empty(t, recorder.Header().Get(StatusHeader))
+			}
+			assert.Equal(t, 0, store.ll.Len())
+		})
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(2)
+	now := time.UnixMilli(1700000000000)
+	store.now = func() time.Time {
+		return now
+	}
+	require.NoError(t, store.Set(ctx, "a", []byte("a"), time.Minute, []string{"t1"}))
+	require.NoError(t, store.Set(ctx, "b", []byte("b"), time.Minute, []string{"t1", "t2"}))
+	// 访问 a 之后，b 是最久没有访问的
+	_, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, "c", []byte("c"), time.Second, []string{"t2"}))
+	_, err = store.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+	val, err := store.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("c"), val)
+
+	// 过期
+	now = now.Add(2 * time.Second)
+	_, err = store.Get(ctx, "c")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	// 按照 tag 失效
+	require.NoError(t, store.InvalidateTags(ctx, "t1"))
+	_, err = store.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	assert.Equal(t, 0, store.ll.Len())
+	assert.Empty(t, store.tags)
+
+	// 容量是 0 的时候按照 1 处理
+	store = NewLocalStore(0)
+	require.NoError(t, store.Set(ctx, "a", []byte("a"), time.Minute, nil))
+	val, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), val)
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LocalStore 本地 LRU 缓存，只在单个实例内有效
+// 超过容量的时候淘汰最久没有访问的
+type LocalStore struct {
+	lock     sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	// tag -> keys
+	tags map[string]map[string]struct{}
+	now  func() time.Time
+}
+
+type localItem struct {
+	key      string
+	val      []byte
+	deadline time.Time
+	tags     []string
+}
+
+// NewLocalStore capacity 小于 1 的时候按照 1 处理
+func NewLocalStore(capacity int) *LocalStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LocalStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+		tags:     make(map[string]map[string]struct{}),
+		now:      time.Now,
+	}
+}
+
+func (l *LocalStore) Get(ctx context.Context, key string) ([]byte, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	item := elem.Value.(*localItem)
+	if l.now().After(item.deadline) {
+		l.remove(elem)
+		return nil, ErrMiss
+	}
+	l.ll.MoveToFront(elem)
+	return item.val, nil
+}
+
+func (l *LocalStore) Set(ctx context.Context, key string, val []byte, ttl time.Duration, tags []string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if elem, ok := l.items[key]; ok {
+		l.remove(elem)
+	}
+	item := &localItem{
+		key:      key,
+		val:      val,
+		deadline: l.now().Add(ttl),
+		tags:     tags,
+	}
+	l.items[key] = l.ll.PushFront(item)
+	for _, tag := range tags {
+		keys, ok := l.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			l.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for l.ll.Len() > l.capacity {
+		l.remove(l.ll.Back())
+	}
+	return nil
+}
+
+func (l *LocalStore) InvalidateTags(ctx context.Context, tags ...string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, tag := range tags {
+		for key := range l.tags[tag] {
+			if elem, ok := l.items[key]; ok {
+				l.remove(elem)
+			}
+		}
+		delete(l.tags, tag)
+	}
+	return nil
+}
+
+func (l *LocalStore) remove(elem *list.Element) {
+	item := elem.Value.(*localItem)
+	l.ll.Remove(elem)
+	delete(l.items, item.key)
+	for _, tag := range item.tags {
+		keys := l.tags[tag]
+		delete(keys, item.key)
+		if len(keys) == 0 {
+			delete(l.tags, tag)
+		}
+	}
+}
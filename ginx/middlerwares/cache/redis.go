@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// tagScript 把缓存的 key 加入 tag 的 set
+// tag 的过期时间只会延长，不会比里面的 key 先过期
+// 只操作一个 key，兼容 redis cluster
+// KEYS[1] 是 tag 的 key；ARGV[1] 是缓存的 key，ARGV[2] 是过期时间，单位毫秒
+const tagScript = `
+redis.call("SADD", KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if redis.call("PTTL", KEYS[1]) < ttl then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 0
+`
+
+// RedisStore 使用 redis 缓存，多个实例共享
+// 每个 tag 对应一个 set，记录带有这个 tag 的 key
+type RedisStore struct {
+	cmd    redis.Cmdable
+	prefix string
+}
+
+func NewRedisStore(cmd redis.Cmdable) *RedisStore {
+	return &RedisStore{
+		cmd:    cmd,
+		prefix: "http_cache",
+	}
+}
+
+func (r *RedisStore) Prefix(prefix string) *RedisStore {
+	r.prefix = prefix
+	return r
+}
+
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := r.cmd.Get(ctx, r.prefix+":"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return val, err
+}
+
+// Set 先把 key 加入 tag，再写入缓存
+// 缓存的 key 和 tag 的 key 可能在 redis cluster 不同的槽上，所以分开执行
+// 加入 tag 失败的时候不写缓存，避免出现没办法失效的缓存
+func (r *RedisStore) Set(ctx context.Context, key string, val []byte, ttl time.Duration, tags []string) error {
+	fullKey := r.prefix + ":" + key
+	for _, tag := range tags {
+		err := r.cmd.Eval(ctx, tagScript, []string{r.tagKey(tag)}, fullKey, ttl.Milliseconds()).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return r.cmd.Set(ctx, fullKey, val, ttl).Err()
+}
+
+// InvalidateTags 逐个删除 key，兼容 redis cluster
+func (r *RedisStore) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		tagKey := r.tagKey(tag)
+		keys, err := r.cmd.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range append(keys, tagKey) {
+			if err = r.cmd.Del(ctx, key).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RedisStore) tagKey(tag string) string {
+	return r.prefix + ":tag:" + tag
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRedisStore(t *testing.T) {
+	r := &fakeRedis{data: map[string]string{}, sets: map[string]map[string]bool{}}
+	store := NewRedisStore(r)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute, []string{"article:1", "list"}))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), time.Minute, []string{"article:2", "list"}))
+	val, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+
+	require.NoError(t, store.InvalidateTags(ctx, "article:1"))
+	_, err = store.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, err = store.Get(ctx, "b")
+	assert.NoError(t, err)
+
+	require.NoError(t, store.InvalidateTags(ctx, "list"))
+	_, err = store.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+	// redis cluster 要求一条命令的 key 在同一个槽上
+	assert.Equal(t, 1, r.maxKeys)
+}
+
+// fakeRedis 只实现了用到的命令，不考虑过期时间
+// 记录一条命令最多用到几个 key
+type fakeRedis struct {
+	redis.Cmdable
+	data    map[string]string
+	sets    map[string]map[string]bool
+	maxKeys int
+}
+
+func (f *fakeRedis) keys(keys ...string) {
+	f.maxKeys = max(f.maxKeys, len(keys))
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	f.keys(key)
+	cmd := redis.NewStringCmd(ctx)
+	val, ok := f.data[key]
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+	cmd.SetVal(val)
+	return cmd
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.keys(key)
+	f.data[key] = string(value.([]byte))
+	cmd := redis.NewStatusCmd(ctx)
+	cmd.SetVal("OK")
+	return cmd
+}
+
+// Eval 只支持 tagScript
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	f.keys(keys...)
+	if f.sets[keys[0]] == nil {
+		f.sets[keys[0]] = map[string]bool{}
+	}
+	f.sets[keys[0]][args[0].(string)] = true
+	cmd := redis.NewCmd(ctx)
+	cmd.SetVal(int64(0))
+	return cmd
+}
+
+func (f *fakeRedis) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
+	f.keys(key)
+	cmd := redis.NewStringSliceCmd(ctx)
+	var res []string
+	for member := range f.sets[key] {
+		res = append(res, member)
+	}
+	cmd.SetVal(res)
+	return cmd
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	f.keys(keys...)
+	for _, key := range keys {
+		delete(f.data, key)
+		delete(f.sets, key)
+	}
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetVal(int64(len(keys)))
+	return cmd
+}
@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss 缓存不存在或者已经过期
+var ErrMiss = errors.New("cache: 缓存未命中")
+
+// Store 缓存响应的存储
+type Store interface {
+	// Get 不存在的时候返回 ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set tags 用于批量失效，例如 article:123
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration, tags []string) error
+	// InvalidateTags 删除带有这些 tag 的所有缓存
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
@@ -0,0 +1,283 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// HeaderKey 客户端通过这个请求头传递幂等键，重试的时候使用同一个值
+	HeaderKey = "Idempotency-Key"
+	// ReplayedHeader 响应是之前保存的结果
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// ErrInFlight 同一个幂等键的请求还在处理中
+var ErrInFlight = ginx.RegisterError(ginx.ReservedCodeMin+http.StatusConflict, "请求正在处理中",
+	http.StatusConflict, accesslog.WarnLevel)
+
+// ErrKeyReused 同一个幂等键用在了请求体不同的请求上
+var ErrKeyReused = ginx.RegisterError(ginx.ReservedCodeMin+http.StatusUnprocessableEntity, "幂等键已经被其它请求使用",
+	http.StatusUnprocessableEntity, accesslog.WarnLevel)
+
+// unlockScript 只删除自己加的锁
+const unlockScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+// Builder 幂等中间件
+// 1. 第一次请求：加锁，执行业务，保存响应
+// 2. 重复请求：直接返回保存的响应
+// 3. 第一次请求还在处理中：返回 409
+// 4. 同一个幂等键，请求体不一样：返回 422
+// 5xx 的响应不保存，客户端可以用同一个幂等键重试
+type Builder struct {
+	cmd    redis.Cmdable
+	l      accesslog.Logger
+	prefix string
+	// 响应保存的时间
+	ttl time.Duration
+	// 锁的过期时间，要大于业务的最长处理时间
+	lockTTL time.Duration
+	// 区分不同的用户，避免不同用户的幂等键冲突
+	scope func(ctx *gin.Context) string
+	// redis 出错的时候是否放行
+	failOpen bool
+}
+
+func NewBuilder(cmd redis.Cmdable, l accesslog.Logger) *Builder {
+	return &Builder{
+		cmd:     cmd,
+		l:       l,
+		prefix:  "idempotency",
+		ttl:     24 * time.Hour,
+		lockTTL: 30 * time.Second,
+		scope: func(ctx *gin.Context) string {
+			return ""
+		},
+	}
+}
+
+func (b *Builder) Prefix(prefix string) *Builder {
+	b.prefix = prefix
+	return b
+}
+
+// TTL 响应保存的时间，默认 24 小时
+func (b *Builder) TTL(ttl time.Duration) *Builder {
+	b.ttl = ttl
+	return b
+}
+
+// LockTTL 锁的过期时间，默认 30 秒
+func (b *Builder) LockTTL(ttl time.Duration) *Builder {
+	b.lockTTL = ttl
+	return b
+}
+
+// Scope 例如返回用户 id
+func (b *Builder) Scope(fn func(ctx *gin.Context) string) *Builder {
+	b.scope = fn
+	return b
+}
+
+// FailOpen redis 出错的时候放行，默认返回 ErrInternal
+// 放行意味着重试的请求可能会被重复处理
+func (b *Builder) FailOpen(failOpen bool) *Builder {
+	b.failOpen = failOpen
+	return b
+}
+
+// Build 没有 Idempotency-Key 请求头的请求直接放行
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idemKey := ctx.GetHeader(HeaderKey)
+		if idemKey == "" {
+			return
+		}
+		key := b.key(ctx, idemKey)
+		l := b.l.WithContext(ctx.Request.Context()).With(accesslog.String("key", key))
+		fingerprint, err := b.fingerprint(ctx)
+		if err != nil {
+			l.Warn("读取请求体失败", accesslog.Error(err))
+			ctx.AbortWithStatusJSON(ginx.ErrBadRequest.HTTPStatus, ginx.Result{
+				Code: ginx.ErrBadRequest.Code,
+				Msg:  ginx.ErrBadRequest.Msg,
+			})
+			return
+		}
+
+		if b.replayIfStored(ctx, l, key, fingerprint) {
+			return
+		}
+
+		lockKey := key + ":lock"
+		token := requestid.New()
+		ok, err := b.cmd.SetNX(ctx, lockKey, token, b.lockTTL).Result()
+		if err != nil {
+			l.Error("幂等加锁失败", accesslog.Error(err))
+			b.failed(ctx)
+			return
+		}
+		if !ok {
+			ctx.AbortWithStatusJSON(ErrInFlight.HTTPStatus, ginx.Result{
+				Code: ErrInFlight.Code,
+				Msg:  ErrInFlight.Msg,
+			})
+			return
+		}
+		defer func() {
+			// 业务可能已经取消了 ctx，这里单独控制超时
+			unlockCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err := b.cmd.Eval(unlockCtx, unlockScript, []string{lockKey}, token).Err()
+			if err != nil {
+				l.Error("幂等释放锁失败", accesslog.Error(err))
+			}
+		}()
+		// 查询和加锁之间，第一次请求可能已经保存了响应并且释放了锁
+		if b.replayIfStored(ctx, l, key, fingerprint) {
+			return
+		}
+
+		writer := &responseWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+		// 换回原本的 Writer，避免外层的中间件继续写入缓存
+		ctx.Writer = writer.ResponseWriter
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		data, err := json.Marshal(storedResponse{
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+			Fingerprint: fingerprint,
+		})
+		if err != nil {
+			l.Error("序列化幂等结果失败", accesslog.Error(err))
+			return
+		}
+		setCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err = b.cmd.Set(setCtx, key, data, b.ttl).Err(); err != nil {
+			l.Error("保存幂等结果失败", accesslog.Error(err))
+		}
+	}
+}
+
+// key 同一个幂等键在不同的接口上互不影响
+// 使用请求的路径而不是路由模板，例如 /orders/1/pay 和 /orders/2/pay 是不同的接口
+func (b *Builder) key(ctx *gin.Context, idemKey string) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s", b.prefix, ctx.Request.Method, ctx.Request.URL.Path, b.scope(ctx), idemKey)
+}
+
+// replayIfStored 返回 true 表示已经响应了，不需要继续处理
+func (b *Builder) replayIfStored(ctx *gin.Context, l accesslog.Logger, key, fingerprint string) bool {
+	resp, err := b.get(ctx, key)
+	switch {
+	case err == nil:
+		if resp.Fingerprint != fingerprint {
+			ctx.AbortWithStatusJSON(ErrKeyReused.HTTPStatus, ginx.Result{
+				Code: ErrKeyReused.Code,
+				Msg:  ErrKeyReused.Msg,
+			})
+			return true
+		}
+		b.replay(ctx, resp)
+		return true
+	case errors.Is(err, redis.Nil):
+		return false
+	default:
+		l.Error("查询幂等结果失败", accesslog.Error(err))
+		b.failed(ctx)
+		return !b.failOpen
+	}
+}
+
+// failed redis 出错，没有开启 FailOpen 的时候返回 ErrInternal
+func (b *Builder) failed(ctx *gin.Context) {
+	if b.failOpen {
+		return
+	}
+	ctx.AbortWithStatusJSON(ginx.ErrInternal.HTTPStatus, ginx.Result{
+		Code: ginx.ErrInternal.Code,
+		Msg:  ginx.ErrInternal.Msg,
+	})
+}
+
+// fingerprint 请求体的摘要，读完之后放回去，业务还可以继续读
+func (b *Builder) fingerprint(ctx *gin.Context) (string, error) {
+	var body []byte
+	if ctx.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (b *Builder) get(ctx context.Context, key string) (storedResponse, error) {
+	var resp storedResponse
+	data, err := b.cmd.Get(ctx, key).Bytes()
+	if err != nil {
+		return resp, err
+	}
+	err = json.Unmarshal(data, &resp)
+	return resp, err
+}
+
+func (b *Builder) replay(ctx *gin.Context, resp storedResponse) {
+	ctx.Header(ReplayedHeader, "true")
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.Data(resp.Status, contentType, resp.Body)
+	ctx.Abort()
+}
+
+type storedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// responseWriter 记录响应体
+type responseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseWriter) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseWriter) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
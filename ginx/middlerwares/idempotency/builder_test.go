@@ -0,0 +1,322 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name    string
+		redis   func() *fakeRedis
+		handler func(cnt int) (int, string)
+		// 依次发送的请求的幂等键
+		keys []string
+		// 依次发送的请求的请求体，为空表示都没有请求体
+		bodies   []string
+		failOpen bool
+
+		wantCodes    []int
+		wantBodies   []string
+		wantReplayed []bool
+		wantCalls    int
+		wantLogged   bool
+	}{
+		{
+			name: "重复请求返回保存的响应",
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:         []string{"abc", "abc"},
+			wantCodes:    []int{http.StatusOK, http.StatusOK},
+			wantBodies:   []string{`{"order_id":1}`, `{"order_id":1}`},
+			wantReplayed: []bool{false, true},
+			wantCalls:    1,
+		},
+		{
+			name: "不同的幂等键",
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:         []string{"abc", "def"},
+			wantCodes:    []int{http.StatusOK, http.StatusOK},
+			wantBodies:   []string{`{"order_id":1}`, `{"order_id":1}`},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name: "没有幂等键",
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:         []string{"", ""},
+			wantCodes:    []int{http.StatusOK, http.StatusOK},
+			wantBodies:   []string{`{"order_id":1}`, `{"order_id":1}`},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name: "5xx 不保存，可以重试",
+			handler: func(cnt int) (int, string) {
+				if cnt == 1 {
+					return http.StatusInternalServerError, `{"code":5}`
+				}
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:         []string{"abc", "abc"},
+			wantCodes:    []int{http.StatusInternalServerError, http.StatusOK},
+			wantBodies:   []string{`{"code":5}`, `{"order_id":1}`},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name: "正在处理中",
+			redis: func() *fakeRedis {
+				r := newFakeRedis()
+				r.data["idempotency:POST:/orders::abc:lock"] = "other"
+				return r
+			},
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:         []string{"abc"},
+			wantCodes:    []int{http.StatusConflict},
+			wantBodies:   []string{`{"code":900409,"msg":"请求正在处理中","data":null}`},
+			wantReplayed: []bool{false},
+			wantCalls:    0,
+		},
+		{
+			name: "redis 出错，拒绝",
+			redis: func() *fakeRedis {
+				r := newFakeRedis()
+				r.err = errors.New("mock error")
+				return r
+			},
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:         []string{"abc"},
+			wantCodes:    []int{http.StatusInternalServerError},
+			wantBodies:   []string{`{"code":900500,"msg":"系统错误","data":null}`},
+			wantReplayed: []bool{false},
+			wantCalls:    0,
+			wantLogged:   true,
+		},
+		{
+			name: "redis 出错，放行",
+			redis: func() *fakeRedis {
+				r := newFakeRedis()
+				r.err = errors.New("mock error")
+				return r
+			},
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			failOpen:     true,
+			keys:         []string{"abc", "abc"},
+			wantCodes:    []int{http.StatusOK, http.StatusOK},
+			wantBodies:   []string{`{"order_id":1}`, `{"order_id":1}`},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+			wantLogged:   true,
+		},
+		{
+			name: "查询之后加锁之前，第一次请求已经处理完了",
+			redis: func() *fakeRedis {
+				r := newFakeRedis()
+				r.beforeSetNX = func(data map[string]string) {
+					data["idempotency:POST:/orders::abc"] = `{"status":200,"content_type":"application/json",` +
+						`"body":"eyJvcmRlcl9pZCI6MX0=","fingerprint":"` + emptyFingerprint + `"}`
+				}
+				return r
+			},
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":2}`
+			},
+			keys:         []string{"abc"},
+			wantCodes:    []int{http.StatusOK},
+			wantBodies:   []string{`{"order_id":1}`},
+			wantReplayed: []bool{true},
+			wantCalls:    0,
+		},
+		{
+			name: "同一个幂等键，请求体不一样",
+			handler: func(cnt int) (int, string) {
+				return http.StatusOK, `{"order_id":1}`
+			},
+			keys:      []string{"abc", "abc", "abc"},
+			bodies:    []string{`{"amount":1}`, `{"amount":2}`, `{"amount":1}`},
+			wantCodes: []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusOK},
+			wantBodies: []string{`{"order_id":1}`,
+				`{"code":900422,"msg":"幂等键已经被其它请求使用","data":null}`, `{"order_id":1}`},
+			wantReplayed: []bool{false, false, true},
+			wantCalls:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newFakeRedis()
+			if tc.redis != nil {
+				r = tc.redis()
+			}
+			l := logtest.NewRecorder()
+			server := gin.New()
+			server.Use(NewBuilder(r, l).FailOpen(tc.failOpen).Build())
+			calls := 0
+			server.POST("/orders", func(ctx *gin.Context) {
+				// 业务还可以读到请求体
+				_, err := io.ReadAll(ctx.Request.Body)
+				require.NoError(t, err)
+				calls++
+				code, body := tc.handler(calls)
+				ctx.Data(code, "application/json", []byte(body))
+			})
+
+			for i, key := range tc.keys {
+				body := ""
+				if len(tc.bodies) > 0 {
+					body = tc.bodies[i]
+				}
+				req, err := http.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+				require.NoError(t, err)
+				if key != "" {
+					req.Header.Set(HeaderKey, key)
+				}
+				recorder := httptest.NewRecorder()
+				server.ServeHTTP(recorder, req)
+				assert.Equal(t, tc.wantCodes[i], recorder.Code)
+				assert.Equal(t, tc.wantBodies[i], recorder.Body.String())
+				assert.Equal(t, tc.wantReplayed[i], recorder.Header().Get(ReplayedHeader) == "true")
+			}
+			assert.Equal(t, tc.wantCalls, calls)
+			assert.Equal(t, tc.wantLogged, len(l.Entries().FilterLevel(accesslog.ErrorLevel)) > 0)
+			if tc.redis == nil {
+				// 锁都释放了
+				for key := range r.data {
+					assert.NotContains(t, key, ":lock")
+				}
+			}
+		})
+	}
+}
+
+// TestBuilder_PathParam 同一个幂等键，路径参数不同的请求互不影响
+func TestBuilder_PathParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newFakeRedis()
+	server := gin.New()
+	var writer gin.ResponseWriter
+	server.Use(func(ctx *gin.Context) {
+		writer = ctx.Writer
+		ctx.Next()
+		// 处理完之后要换回原本的 Writer
+		assert.Equal(t, writer, ctx.Writer)
+	}, NewBuilder(r, logtest.NewRecorder()).Build())
+	calls := 0
+	server.POST("/orders/:id/pay", func(ctx *gin.Context) {
+		calls++
+		ctx.Data(http.StatusOK, "application/json", []byte(`{"order_id":`+ctx.Param("id")+`}`))
+	})
+
+	for _, id := range []string{"1", "2", "1"} {
+		req, err := http.NewRequest(http.MethodPost, "/orders/"+id+"/pay", nil)
+		require.NoError(t, err)
+		req.Header.Set(HeaderKey, "abc")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"order_id":`+id+`}`, recorder.Body.String())
+	}
+	assert.Equal(t, 2, calls)
+}
+
+// emptyFingerprint 没有请求体的时候的摘要
+const emptyFingerprint = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// fakeRedis 只实现了用到的命令，不考虑过期时间
+type fakeRedis struct {
+	redis.Cmdable
+	lock sync.Mutex
+	data map[string]string
+	err  error
+	// 模拟加锁之前别的请求修改了数据
+	beforeSetNX func(data map[string]string)
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: map[string]string{}}
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	cmd := redis.NewStringCmd(ctx)
+	if f.err != nil {
+		cmd.SetErr(f.err)
+		return cmd
+	}
+	val, ok := f.data[key]
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+	cmd.SetVal(val)
+	return cmd
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	cmd := redis.NewBoolCmd(ctx)
+	if f.err != nil {
+		cmd.SetErr(f.err)
+		return cmd
+	}
+	if f.beforeSetNX != nil {
+		f.beforeSetNX(f.data)
+	}
+	if _, ok := f.data[key]; ok {
+		cmd.SetVal(false)
+		return cmd
+	}
+	f.data[key] = value.(string)
+	cmd.SetVal(true)
+	return cmd
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	cmd := redis.NewStatusCmd(ctx)
+	f.data[key] = string(value.([]byte))
+	cmd.SetVal("OK")
+	return cmd
+}
+
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	cmd := redis.NewCmd(ctx)
+	if f.data[keys[0]] == args[0] {
+		delete(f.data, keys[0])
+		cmd.SetVal(int64(1))
+		return cmd
+	}
+	cmd.SetVal(int64(0))
+	return cmd
+}
@@ -0,0 +1,275 @@
+package jwtx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// AccessTokenHeader 登录之后通过这个响应头返回 access token
+	AccessTokenHeader = "x-jwt-token"
+	// RefreshTokenHeader 登录之后通过这个响应头返回 refresh token
+	RefreshTokenHeader = "x-refresh-token"
+)
+
+// 签发的时候写到 header 的 typ 里面，校验的时候检查，避免两种 token 混用
+// access token 参考 RFC 9068
+const (
+	typAccess  = "at+jwt"
+	typRefresh = "rt+jwt"
+)
+
+// Builder 校验 access token，并且 ctx.Set("user", claims)
+// 配合 ginx.WrapToken 和 ginx.WrapBodyAndToken 使用
+type Builder[C Claims] struct {
+	cmd       redis.Cmdable
+	newClaims func() C
+	method    *jwt.SigningMethodHMAC
+
+	// 运行期间可以替换，用于轮换密钥
+	accessKeys atomic.Pointer[Keys]
+	// 为 nil 的时候使用 accessKeys，轮换 accessKeys 之后 refresh token 也跟着轮换
+	refreshKeys atomic.Pointer[Keys]
+	// refresh token 的有效期，也是退出登录之后 ssid 在 redis 里面保留的时间
+	refreshExpire time.Duration
+
+	ignorePaths map[string]struct{}
+	// 请求头没有 token 的时候，从这个 cookie 读取
+	cookieName string
+}
+
+// NewBuilder newClaims 用于创建解析的目标，例如
+//
+//	func() *UserClaims { return &UserClaims{} }
+func NewBuilder[C Claims](cmd redis.Cmdable, accessKeys Keys, newClaims func() C) *Builder[C] {
+	b := &Builder[C]{
+		cmd:           cmd,
+		newClaims:     newClaims,
+		method:        jwt.SigningMethodHS256,
+		refreshExpire: 7 * 24 * time.Hour,
+		ignorePaths:   make(map[string]struct{}),
+	}
+	b.accessKeys.Store(&accessKeys)
+	return b
+}
+
+// AccessKeys 替换 access token 的密钥，运行期间可以调用，用于轮换密钥
+func (b *Builder[C]) AccessKeys(keys Keys) *Builder[C] {
+	b.accessKeys.Store(&keys)
+	return b
+}
+
+// SigningMethod 签名算法，默认是 HS256
+func (b *Builder[C]) SigningMethod(method *jwt.SigningMethodHMAC) *Builder[C] {
+	b.method = method
+	return b
+}
+
+// RefreshKeys refresh token 的密钥，默认和 access token 一样，运行期间可以调用
+// 两种 token 通过 typ 区分，不会混用，不过还是建议使用不同的密钥
+func (b *Builder[C]) RefreshKeys(keys Keys) *Builder[C] {
+	b.refreshKeys.Store(&keys)
+	return b
+}
+
+// loadRefreshKeys 没有单独设置 refresh token 的密钥时，使用当前的 access token 密钥
+func (b *Builder[C]) loadRefreshKeys() *Keys {
+	if keys := b.refreshKeys.Load(); keys != nil {
+		return keys
+	}
+	return b.accessKeys.Load()
+}
+
+// RefreshExpire refresh token 的有效期，默认 7 天
+func (b *Builder[C]) RefreshExpire(expire time.Duration) *Builder[C] {
+	b.refreshExpire = expire
+	return b
+}
+
+// IgnorePaths 不需要登录的路径，例如 /users/login
+func (b *Builder[C]) IgnorePaths(paths ...string) *Builder[C] {
+	for _, path := range paths {
+		b.ignorePaths[path] = struct{}{}
+	}
+	return b
+}
+
+// TokenCookie 请求头没有 token 的时候，从这个 cookie 读取
+func (b *Builder[C]) TokenCookie(name string) *Builder[C] {
+	b.cookieName = name
+	return b
+}
+
+func (b *Builder[C]) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := b.ignorePaths[ctx.Request.URL.Path]; ok {
+			return
+		}
+		tokenStr := b.ExtractToken(ctx)
+		claims, err := b.parse(tokenStr, b.accessKeys.Load(), typAccess)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		err = b.CheckSession(ctx, claims.GetSsid())
+		if err != nil {
+			// redis 出错的时候也拒绝，也可以考虑降级放行
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Set("user", claims)
+	}
+}
+
+// ExtractToken 优先使用 Authorization: Bearer xxx，没有的话读取 cookie
+func (b *Builder[C]) ExtractToken(ctx *gin.Context) string {
+	header := ctx.GetHeader("Authorization")
+	if header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if b.cookieName == "" {
+		return ""
+	}
+	token, err := ctx.Cookie(b.cookieName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// SetJWTToken 签发 access token，通过 x-jwt-token 响应头返回
+// 过期时间之类的由 claims 自己设置
+func (b *Builder[C]) SetJWTToken(ctx *gin.Context, claims C) error {
+	token, err := b.sign(claims, b.accessKeys.Load(), typAccess)
+	if err != nil {
+		return err
+	}
+	ctx.Header(AccessTokenHeader, token)
+	return nil
+}
+
+// SetRefreshToken 签发 refresh token，通过 x-refresh-token 响应头返回
+func (b *Builder[C]) SetRefreshToken(ctx *gin.Context, claims C) error {
+	token, err := b.sign(claims, b.loadRefreshKeys(), typRefresh)
+	if err != nil {
+		return err
+	}
+	ctx.Header(RefreshTokenHeader, token)
+	return nil
+}
+
+// SetLoginToken 登录成功，同时签发 access token 和 refresh token
+// 两者应该使用同一个 ssid
+func (b *Builder[C]) SetLoginToken(ctx *gin.Context, access C, refresh C) error {
+	if err := b.SetJWTToken(ctx, access); err != nil {
+		return err
+	}
+	return b.SetRefreshToken(ctx, refresh)
+}
+
+// ParseRefreshToken 刷新 token 的接口使用，从请求里面解析并且校验 refresh token
+// 之后业务调用 SetJWTToken 签发新的 access token
+func (b *Builder[C]) ParseRefreshToken(ctx *gin.Context) (C, error) {
+	claims, err := b.parse(b.ExtractToken(ctx), b.loadRefreshKeys(), typRefresh)
+	if err != nil {
+		return claims, err
+	}
+	return claims, b.CheckSession(ctx, claims.GetSsid())
+}
+
+// ClearToken 退出登录
+// 清空响应头里面的 token，并且在 redis 里面记录 ssid 已经失效
+func (b *Builder[C]) ClearToken(ctx *gin.Context) error {
+	ctx.Header(AccessTokenHeader, "")
+	ctx.Header(RefreshTokenHeader, "")
+	val, ok := ctx.Get("user")
+	if !ok {
+		return ErrTokenNotFound
+	}
+	claims, ok := val.(C)
+	if !ok {
+		return ErrTokenInvalid
+	}
+	return b.Revoke(ctx, claims.GetSsid())
+}
+
+// Revoke 让 ssid 失效，access token 和 refresh token 都不能再使用
+func (b *Builder[C]) Revoke(ctx context.Context, ssid string) error {
+	if ssid == "" {
+		return ErrSsidEmpty
+	}
+	return b.cmd.Set(ctx, b.ssidKey(ssid), "", b.refreshExpire).Err()
+}
+
+// CheckSession ssid 已经失效的时候返回 ErrSessionRevoked
+// 没有 ssid 的 token 没办法退出登录，返回 ErrSsidEmpty
+func (b *Builder[C]) CheckSession(ctx context.Context, ssid string) error {
+	if ssid == "" {
+		return ErrSsidEmpty
+	}
+	cnt, err := b.cmd.Exists(ctx, b.ssidKey(ssid)).Result()
+	if err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (b *Builder[C]) ssidKey(ssid string) string {
+	return fmt.Sprintf("users:ssid:%s", ssid)
+}
+
+func (b *Builder[C]) sign(claims C, keys *Keys, typ string) (string, error) {
+	key, err := keys.current()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(b.method, claims)
+	token.Header["kid"] = keys.Current
+	token.Header["typ"] = typ
+	return token.SignedString(key)
+}
+
+func (b *Builder[C]) parse(tokenStr string, keys *Keys, typ string) (C, error) {
+	claims := b.newClaims()
+	if tokenStr == "" {
+		return claims, ErrTokenNotFound
+	}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["typ"] != typ {
+			return nil, ErrTokenType
+		}
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return keys.current()
+		}
+		return keys.get(kid)
+	}, jwt.WithValidMethods([]string{b.method.Alg()}))
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return claims, ErrKeyNotFound
+		}
+		if errors.Is(err, ErrTokenType) {
+			return claims, ErrTokenType
+		}
+		return claims, fmt.Errorf("%w %w", ErrTokenInvalid, err)
+	}
+	if !token.Valid {
+		return claims, ErrTokenInvalid
+	}
+	return claims, nil
+}
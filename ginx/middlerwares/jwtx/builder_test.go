@@ -0,0 +1,331 @@
+package jwtx
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type userClaims struct {
+	jwt.RegisteredClaims
+	Uid  int64
+	Ssid string
+}
+
+func (u *userClaims) GetSsid() string {
+	return u.Ssid
+}
+
+func newUserClaims() *userClaims {
+	return &userClaims{}
+}
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oldKeys := Keys{Current: "v1", Keys: map[string][]byte{"v1": []byte("old-key")}}
+	keys := Keys{Current: "v2", Keys: map[string][]byte{
+		"v1": []byte("old-key"),
+		"v2": []byte("new-key"),
+	}}
+	claims := func(ssid string, expire time.Duration) *userClaims {
+		return &userClaims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(expire))},
+			Uid:              123,
+			Ssid:             ssid,
+		}
+	}
+	signTyp := func(t *testing.T, c *userClaims, k Keys, typ string) string {
+		b := NewBuilder[*userClaims](nil, k, newUserClaims)
+		token, err := b.sign(c, &k, typ)
+		require.NoError(t, err)
+		return token
+	}
+	sign := func(t *testing.T, c *userClaims, k Keys) string {
+		return signTyp(t, c, k, typAccess)
+	}
+	// 没有 kid 的旧 token
+	legacyToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims("ssid-1", time.Minute))
+	legacyToken.Header["typ"] = typAccess
+	legacy, err := legacyToken.SignedString([]byte("new-key"))
+	require.NoError(t, err)
+	// 没有 typ 的 token
+	untyped, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims("ssid-1", time.Minute)).
+		SignedString([]byte("new-key"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		path  string
+		req   func(t *testing.T, req *http.Request)
+		redis func() redis.Cmdable
+
+		wantCode int
+		wantUid  int64
+	}{
+		{
+			name: "请求头",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("ssid-1", time.Minute), keys))
+			},
+			wantCode: http.StatusOK,
+			wantUid:  123,
+		},
+		{
+			name: "cookie",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: "jwt", Value: sign(t, claims("ssid-1", time.Minute), keys)})
+			},
+			wantCode: http.StatusOK,
+			wantUid:  123,
+		},
+		{
+			name: "旧密钥签发的 token",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("ssid-1", time.Minute), oldKeys))
+			},
+			wantCode: http.StatusOK,
+			wantUid:  123,
+		},
+		{
+			name: "没有 kid 的 token",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+legacy)
+			},
+			wantCode: http.StatusOK,
+			wantUid:  123,
+		},
+		{
+			name: "没有 typ 的 token",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+untyped)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "refresh token 当成 access token 使用",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+signTyp(t, claims("ssid-1", time.Minute), keys, typRefresh))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "没有 ssid",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("", time.Minute), keys))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "未知的 kid",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				k := Keys{Current: "v3", Keys: map[string][]byte{"v3": []byte("new-key")}}
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("ssid-1", time.Minute), k))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "过期",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("ssid-1", -time.Minute), keys))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "没有 token",
+			path:     "/profile",
+			req:      func(t *testing.T, req *http.Request) {},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "忽略的路径",
+			path:     "/login",
+			req:      func(t *testing.T, req *http.Request) {},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "已经退出登录",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("ssid-revoked", time.Minute), keys))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "redis 出错",
+			path: "/profile",
+			req: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+sign(t, claims("ssid-1", time.Minute), keys))
+			},
+			redis: func() redis.Cmdable {
+				return &fakeRedis{err: errors.New("mock error")}
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := redis.Cmdable(&fakeRedis{revoked: map[string]bool{"users:ssid:ssid-revoked": true}})
+			if tc.redis != nil {
+				cmd = tc.redis()
+			}
+			b := NewBuilder[*userClaims](cmd, keys, newUserClaims).
+				IgnorePaths("/login").
+				TokenCookie("jwt")
+			server := gin.New()
+			server.Use(b.Build())
+			var uid int64
+			handler := func(ctx *gin.Context) {
+				if val, ok := ctx.Get("user"); ok {
+					uid = val.(*userClaims).Uid
+				}
+				ctx.Status(http.StatusOK)
+			}
+			server.GET("/profile", handler)
+			server.GET("/login", handler)
+
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			tc.req(t, req)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantUid, uid)
+		})
+	}
+}
+
+func TestBuilder_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accessKeys := Keys{Current: "v1", Keys: map[string][]byte{"v1": []byte("access-key")}}
+	refreshKeys := Keys{Current: "v1", Keys: map[string][]byte{"v1": []byte("refresh-key")}}
+	cmd := &fakeRedis{revoked: map[string]bool{}}
+	b := NewBuilder[*userClaims](cmd, accessKeys, newUserClaims).
+		RefreshKeys(refreshKeys).
+		IgnorePaths("/login", "/refresh")
+	server := gin.New()
+	server.Use(b.Build())
+	server.POST("/login", func(ctx *gin.Context) {
+		c := &userClaims{Uid: 123, Ssid: "ssid-1"}
+		require.NoError(t, b.SetLoginToken(ctx, c, c))
+	})
+	server.POST("/refresh", func(ctx *gin.Context) {
+		c, err := b.ParseRefreshToken(ctx)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, b.SetJWTToken(ctx, c))
+	})
+	server.POST("/logout", func(ctx *gin.Context) {
+		require.NoError(t, b.ClearToken(ctx))
+	})
+	do := func(path, token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	resp := do("/login", "")
+	access := resp.Header().Get(AccessTokenHeader)
+	refresh := resp.Header().Get(RefreshTokenHeader)
+	require.NotEmpty(t, access)
+	require.NotEmpty(t, refresh)
+
+	// refresh token 不能当成 access token 使用
+	assert.Equal(t, http.StatusUnauthorized, do("/logout", refresh).Code)
+	resp = do("/refresh", refresh)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.NotEmpty(t, resp.Header().Get(AccessTokenHeader))
+
+	assert.Equal(t, http.StatusOK, do("/logout", access).Code)
+	assert.True(t, cmd.revoked["users:ssid:ssid-1"])
+	// 退出之后两个 token 都不能用了
+	assert.Equal(t, http.StatusUnauthorized, do("/logout", access).Code)
+	assert.Equal(t, http.StatusUnauthorized, do("/refresh", refresh).Code)
+}
+
+func TestBuilder_SameKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := Keys{Current: "v1", Keys: map[string][]byte{"v1": []byte("key")}}
+	b := NewBuilder[*userClaims](&fakeRedis{revoked: map[string]bool{}}, keys, newUserClaims)
+	c := &userClaims{Uid: 123, Ssid: "ssid-1"}
+	access, err := b.sign(c, b.accessKeys.Load(), typAccess)
+	require.NoError(t, err)
+	refresh, err := b.sign(c, b.loadRefreshKeys(), typRefresh)
+	require.NoError(t, err)
+
+	// 默认使用同一套密钥，也不能混用
+	_, err = b.parse(refresh, b.accessKeys.Load(), typAccess)
+	assert.ErrorIs(t, err, ErrTokenType)
+	_, err = b.parse(access, b.loadRefreshKeys(), typRefresh)
+	assert.ErrorIs(t, err, ErrTokenType)
+	_, err = b.parse(access, b.accessKeys.Load(), typAccess)
+	assert.NoError(t, err)
+
+	// 运行期间轮换密钥，旧的密钥删除之后，旧的 token 就不能用了
+	b.AccessKeys(Keys{Current: "v2", Keys: map[string][]byte{"v2": []byte("new-key")}})
+	_, err = b.parse(access, b.accessKeys.Load(), typAccess)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	// 没有单独设置 refresh token 的密钥，跟着 access token 一起轮换
+	_, err = b.parse(refresh, b.loadRefreshKeys(), typRefresh)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	refresh, err = b.sign(c, b.loadRefreshKeys(), typRefresh)
+	require.NoError(t, err)
+	_, err = b.parse(refresh, b.loadRefreshKeys(), typRefresh)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, b.Revoke(context.Background(), ""), ErrSsidEmpty)
+}
+
+// fakeRedis 只实现了用到的 Exists 和 Set
+type fakeRedis struct {
+	redis.Cmdable
+	revoked map[string]bool
+	err     error
+}
+
+func (f *fakeRedis) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx)
+	if f.err != nil {
+		cmd.SetErr(f.err)
+		return cmd
+	}
+	var cnt int64
+	for _, key := range keys {
+		if f.revoked[key] {
+			cnt++
+		}
+	}
+	cmd.SetVal(cnt)
+	return cmd
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	cmd := redis.NewStatusCmd(ctx)
+	f.revoked[key] = true
+	cmd.SetVal("OK")
+	return cmd
+}
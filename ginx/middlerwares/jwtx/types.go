@@ -0,0 +1,47 @@
+package jwtx
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrTokenNotFound = errors.New("jwtx: 没有 token")
+	ErrTokenInvalid  = errors.New("jwtx: token 无效")
+	ErrKeyNotFound   = errors.New("jwtx: kid 对应的密钥不存在")
+	// ErrTokenType access token 和 refresh token 混用
+	ErrTokenType = errors.New("jwtx: token 类型错误")
+	// ErrSsidEmpty claims 里面没有 ssid，没办法退出登录
+	ErrSsidEmpty = errors.New("jwtx: ssid 为空")
+	// ErrSessionRevoked 已经退出登录
+	ErrSessionRevoked = errors.New("jwtx: 会话已经失效")
+)
+
+// Claims 业务的 Claims 需要带上 ssid，用于退出登录
+// 一般是在 jwt.RegisteredClaims 的基础上加上 Uid, Ssid 之类的字段
+type Claims interface {
+	jwt.Claims
+	GetSsid() string
+}
+
+// Keys 签名密钥，支持轮换
+// 签发使用 Current 对应的密钥，并且把 Current 写到 header 的 kid 里面
+// 校验的时候按照 kid 查找，没有 kid 的旧 token 使用 Current
+// 轮换的时候先加入新的密钥并切换 Current，旧的密钥等 token 都过期了再删除
+// 运行期间通过 Builder.AccessKeys 和 Builder.RefreshKeys 替换
+type Keys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k Keys) current() ([]byte, error) {
+	return k.get(k.Current)
+}
+
+func (k Keys) get(kid string) ([]byte, error) {
+	key, ok := k.Keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
@@ -3,9 +3,12 @@ package logger
 import (
 	"bytes"
 	"context"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/atomic"
 	"io"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -15,6 +18,8 @@ type AccessLog struct {
 	Method string `json:"method"`
 	// 请求url
 	Url string `json:"url"`
+	// 请求头
+	ReqHeader map[string]string `json:"req_header,omitempty"`
 	// 请求体
 	ReqBody string `json:"req_body"`
 	// 响应体
@@ -25,24 +30,51 @@ type AccessLog struct {
 	Status int `json:"status"`
 }
 
+// MarshalLogFields 实现 accesslog.ObjectMarshaler
+// 可以直接使用 accesslog.Object("access", al) 输出，避免反射
+func (al *AccessLog) MarshalLogFields() []accesslog.Field {
+	fields := []accesslog.Field{
+		accesslog.String("method", al.Method),
+		accesslog.String("url", al.Url),
+		accesslog.String("duration", al.Duration),
+		accesslog.Int64("status", int64(al.Status)),
+	}
+	if al.ReqBody != "" {
+		fields = append(fields, accesslog.String("req_body", al.ReqBody))
+	}
+	if al.RespBody != "" {
+		fields = append(fields, accesslog.String("resp_body", al.RespBody))
+	}
+	for key, val := range al.ReqHeader {
+		fields = append(fields, accesslog.String("req_header."+key, val))
+	}
+	return fields
+}
+
 // Builder 注意点：
 // 1. 防止日志内容过多。URL 可能很长，请求体，响应体都可能很大，要考虑是不是完全输出到日志里面
 // 2. 考虑 1 的问题，以及用户可能换用不同的日志框架，所以要有足够的灵活性
 // 3. 考虑动态开关，结合监听配置文件，要小心并发安全
+// 4. 请求体，响应体，请求头可能有密码、token 等敏感信息，输出之前要脱敏
 type Builder struct {
-	allowReqBody  *atomic.Bool
-	allowRespBody *atomic.Bool
+	allowReqBody   *atomic.Bool
+	allowRespBody  *atomic.Bool
+	allowReqHeader *atomic.Bool
 	// 自己确认日志级别
 	loggerFunc func(ctx context.Context, al *AccessLog)
 	maxLength  *atomic.Int64
+	// 脱敏，默认屏蔽 accesslog.DefaultSensitiveKeys
+	redactor *atomic.Pointer[accesslog.Redactor]
 }
 
 func NewBuilder(fn func(ctx context.Context, al *AccessLog)) *Builder {
 	return &Builder{
-		allowReqBody:  atomic.NewBool(false),
-		allowRespBody: atomic.NewBool(false),
-		loggerFunc:    fn,
-		maxLength:     atomic.NewInt64(1024),
+		allowReqBody:   atomic.NewBool(false),
+		allowRespBody:  atomic.NewBool(false),
+		allowReqHeader: atomic.NewBool(false),
+		loggerFunc:     fn,
+		maxLength:      atomic.NewInt64(1024),
+		redactor:       atomic.NewPointer(accesslog.NewRedactor(accesslog.DefaultSensitiveKeys...)),
 	}
 }
 
@@ -58,6 +90,38 @@ func (b *Builder) AllowRespBody() *Builder {
 	return b
 }
 
+// AllowReqHeader 是否打印请求头
+func (b *Builder) AllowReqHeader() *Builder {
+	b.allowReqHeader.Store(true)
+	return b
+}
+
+// SetAllowReqBody 动态开关请求体，可以在配置变更的时候调用
+func (b *Builder) SetAllowReqBody(allow bool) *Builder {
+	b.allowReqBody.Store(allow)
+	return b
+}
+
+// SetAllowRespBody 动态开关响应体
+func (b *Builder) SetAllowRespBody(allow bool) *Builder {
+	b.allowRespBody.Store(allow)
+	return b
+}
+
+// SetAllowReqHeader 动态开关请求头
+func (b *Builder) SetAllowReqHeader(allow bool) *Builder {
+	b.allowReqHeader.Store(allow)
+	return b
+}
+
+// Redactor 设置脱敏规则，作用于 url 参数，请求头，请求体和响应体
+// 传入 nil 代表不脱敏，可以在配置变更的时候调用
+// r 设置之后不要再修改，需要调整的时候创建新的 Redactor
+func (b *Builder) Redactor(r *accesslog.Redactor) *Builder {
+	b.redactor.Store(r)
+	return b
+}
+
 // MaxLength 打印的最大长度
 func (b *Builder) MaxLength(maxLength int64) *Builder {
 	b.maxLength.Store(maxLength)
@@ -68,12 +132,13 @@ func (b *Builder) Builder() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var (
 			//请求处理开始时间
-			start         = time.Now()
-			url           = ctx.Request.URL.String()
-			urlLen        = int64(len(url))
-			maxLength     = b.maxLength.Load()
-			allowReqBody  = b.allowReqBody.Load()
-			allowRespBody = b.allowRespBody.Load()
+			start          = time.Now()
+			url            = b.redactURL(ctx)
+			urlLen         = int64(len(url))
+			maxLength      = b.maxLength.Load()
+			allowReqBody   = b.allowReqBody.Load()
+			allowRespBody  = b.allowRespBody.Load()
+			allowReqHeader = b.allowReqHeader.Load()
 		)
 		if urlLen > maxLength {
 			url = url[:maxLength]
@@ -82,11 +147,16 @@ func (b *Builder) Builder() gin.HandlerFunc {
 			Method: ctx.Request.Method,
 			Url:    url,
 		}
+		if allowReqHeader {
+			accessLog.ReqHeader = b.redactHeader(ctx)
+		}
 		if allowReqBody && ctx.Request.Body != nil {
 			body, _ := ctx.GetRawData()
 			// Request.Body 是 io.ReadCloser，steam(流)对象，所以只能读取一次，因此读取后，要放回去
 			ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
 
+			// 先脱敏再截断，截断后的 JSON 无法解析
+			body = b.redactBody(body)
 			if int64(len(body)) >= maxLength {
 				body = body[:maxLength]
 			}
@@ -94,16 +164,26 @@ func (b *Builder) Builder() gin.HandlerFunc {
 			accessLog.ReqBody = string(body)
 		}
 
+		var writer *responseWriter
 		if allowRespBody {
 			// response 回调
-			ctx.Writer = responseWriter{
+			writer = &responseWriter{
 				ResponseWriter: ctx.Writer,
 				al:             accessLog,
-				maxLength:      maxLength,
 			}
+			ctx.Writer = writer
 		}
 
 		defer func() {
+			if writer != nil {
+				ctx.Writer = writer.ResponseWriter
+				// 响应体可能分多次写入，全部写完之后再脱敏，分块之后的 JSON 无法解析
+				respData := b.redactBody(writer.body.Bytes())
+				if int64(len(respData)) > maxLength {
+					respData = respData[:maxLength]
+				}
+				accessLog.RespBody = string(respData)
+			}
 			accessLog.Duration = time.Since(start).String()
 			//日志打印
 			b.loggerFunc(ctx, accessLog)
@@ -114,23 +194,79 @@ func (b *Builder) Builder() gin.HandlerFunc {
 	}
 }
 
+// redactURL 脱敏 url 参数
+// 只替换命中的参数值，其它参数保持原本的顺序和编码，替换的内容不做转义
+func (b *Builder) redactURL(ctx *gin.Context) string {
+	redactor := b.redactor.Load()
+	if redactor == nil || ctx.Request.URL.RawQuery == "" {
+		return ctx.Request.URL.String()
+	}
+	pairs := strings.Split(ctx.Request.URL.RawQuery, "&")
+	for i, pair := range pairs {
+		rawKey, rawVal, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if res := redactor.RedactValue(key, rawVal); res != rawVal {
+			pairs[i] = rawKey + "=" + res
+			continue
+		}
+		// 编码之后可能匹配不上，例如 Bearer 后面的空格
+		val, err := url.QueryUnescape(rawVal)
+		if err != nil {
+			continue
+		}
+		if res := redactor.RedactString(val); res != val {
+			pairs[i] = rawKey + "=" + res
+		}
+	}
+	// 复制一份，不能修改原始请求
+	u := *ctx.Request.URL
+	u.RawQuery = strings.Join(pairs, "&")
+	return u.String()
+}
+
+// redactHeader 脱敏请求头，例如 Authorization 和 Cookie
+func (b *Builder) redactHeader(ctx *gin.Context) map[string]string {
+	redactor := b.redactor.Load()
+	res := make(map[string]string, len(ctx.Request.Header))
+	for key, vals := range ctx.Request.Header {
+		val := strings.Join(vals, ";")
+		if redactor != nil {
+			val = redactor.RedactValue(key, val)
+		}
+		res[key] = val
+	}
+	return res
+}
+
+func (b *Builder) redactBody(body []byte) []byte {
+	redactor := b.redactor.Load()
+	if redactor == nil || len(body) == 0 {
+		return body
+	}
+	return redactor.RedactJSON(body)
+}
+
+// responseWriter 缓存响应体，请求处理完之后统一脱敏
 type responseWriter struct {
 	al *AccessLog
 	gin.ResponseWriter
-	maxLength int64
+	body bytes.Buffer
 }
 
-func (r responseWriter) WriteHeader(statusCode int) {
+func (r *responseWriter) WriteHeader(statusCode int) {
 	r.al.Status = statusCode
 	r.ResponseWriter.WriteHeader(statusCode)
 }
 
-func (r responseWriter) Write(data []byte) (int, error) {
-	curLen := int64(len(data))
-	respData := data
-	if curLen > r.maxLength {
-		respData = data[:r.maxLength]
-	}
-	r.al.RespBody = string(respData)
+func (r *responseWriter) Write(data []byte) (int, error) {
+	r.body.Write(data)
 	return r.ResponseWriter.Write(data)
 }
+
+func (r *responseWriter) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuilder_Redact(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var al *AccessLog
+	server := gin.New()
+	server.Use(NewBuilder(func(ctx context.Context, log *AccessLog) {
+		al = log
+	}).AllowReqBody().AllowRespBody().AllowReqHeader().Builder())
+	server.POST("/login", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"access_token": "abc", "msg": "OK"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login?token=abc&from=app&name=%E5%A4%A7",
+		bytes.NewBufferString(`{"email":"a@qq.com","password":"123456"}`))
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Content-Type", "application/json")
+	server.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 保持原本的顺序和编码，替换的内容不转义
+	assert.Equal(t, "/login?token=******&from=app&name=%E5%A4%A7", al.Url)
+	assert.Equal(t, "******", al.ReqHeader["Authorization"])
+	assert.Equal(t, "application/json", al.ReqHeader["Content-Type"])
+	assert.Equal(t, `{"email":"a@qq.com","password":"******"}`, al.ReqBody)
+	assert.Equal(t, `{"access_token":"******","msg":"OK"}`, al.RespBody)
+	assert.Equal(t, http.StatusOK, al.Status)
+}
+
+// TestBuilder_RedactChunkedResp 响应体分多次写入的时候，整体脱敏
+func TestBuilder_RedactChunkedResp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var al *AccessLog
+	server := gin.New()
+	var writer gin.ResponseWriter
+	server.Use(func(ctx *gin.Context) {
+		writer = ctx.Writer
+		ctx.Next()
+		// 处理完之后要换回原本的 Writer
+		assert.Equal(t, writer, ctx.Writer)
+	}, NewBuilder(func(ctx context.Context, log *AccessLog) {
+		al = log
+	}).AllowRespBody().Builder())
+	server.GET("/token", func(ctx *gin.Context) {
+		ctx.Header("Content-Type", "application/json")
+		ctx.Status(http.StatusOK)
+		_, _ = ctx.Writer.Write([]byte(`{"access_token":`))
+		_, _ = ctx.Writer.WriteString(`"abc","msg":"OK"}`)
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/token", nil))
+
+	assert.Equal(t, `{"access_token":"abc","msg":"OK"}`, recorder.Body.String())
+	assert.Equal(t, `{"access_token":"******","msg":"OK"}`, al.RespBody)
+}
@@ -0,0 +1,24 @@
+package logger
+
+import "github.com/dadaxiaoxiao/go-pkg/configx"
+
+// Config 对应 Builder 的动态开关
+type Config struct {
+	AllowReqBody   bool  `yaml:"allowReqBody" json:"allowReqBody"`
+	AllowRespBody  bool  `yaml:"allowRespBody" json:"allowRespBody"`
+	AllowReqHeader bool  `yaml:"allowReqHeader" json:"allowReqHeader"`
+	MaxLength      int64 `yaml:"maxLength" json:"maxLength"`
+}
+
+// BindConfig 订阅 configx 的配置，变更的时候更新 b 的开关
+// MaxLength 为 0 的时候保持 Builder 原本的值
+func BindConfig[T any](m *configx.Manager[T], selector func(T) Config, b *Builder) {
+	configx.Subscribe(m, selector, func(cfg Config) {
+		b.SetAllowReqBody(cfg.AllowReqBody).
+			SetAllowRespBody(cfg.AllowRespBody).
+			SetAllowReqHeader(cfg.AllowReqHeader)
+		if cfg.MaxLength > 0 {
+			b.MaxLength(cfg.MaxLength)
+		}
+	})
+}
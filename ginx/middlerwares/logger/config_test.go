@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type appConfig struct {
+	Log Config
+}
+
+// staticSource 每次加载都返回 cfg
+type staticSource struct {
+	cfg *appConfig
+}
+
+func (s staticSource) Apply(ctx context.Context, dst any) error {
+	*dst.(*appConfig) = *s.cfg
+	return nil
+}
+
+func TestBindConfig(t *testing.T) {
+	cfg := &appConfig{Log: Config{AllowReqBody: true}}
+	m := configx.NewManager[appConfig](accesslog.NewNopLogger(), staticSource{cfg: cfg})
+	require.NoError(t, m.Load(context.Background()))
+	b := NewBuilder(func(ctx context.Context, al *AccessLog) {})
+	BindConfig(m, func(c appConfig) Config {
+		return c.Log
+	}, b)
+	assert.True(t, b.allowReqBody.Load())
+	assert.False(t, b.allowRespBody.Load())
+	// MaxLength 为 0 的时候保持原本的值
+	assert.Equal(t, int64(1024), b.maxLength.Load())
+
+	cfg.Log = Config{AllowRespBody: true, MaxLength: 2048}
+	require.NoError(t, m.Load(context.Background()))
+	assert.False(t, b.allowReqBody.Load())
+	assert.True(t, b.allowRespBody.Load())
+	assert.Equal(t, int64(2048), b.maxLength.Load())
+}
+
+// TestBindConfig_BeforeLoad 加载之前绑定，不会用零值的配置把开关关掉
+func TestBindConfig_BeforeLoad(t *testing.T) {
+	cfg := &appConfig{Log: Config{AllowRespBody: true}}
+	m := configx.NewManager[appConfig](accesslog.NewNopLogger(), staticSource{cfg: cfg})
+	b := NewBuilder(func(ctx context.Context, al *AccessLog) {}).AllowReqBody()
+	BindConfig(m, func(c appConfig) Config {
+		return c.Log
+	}, b)
+	assert.True(t, b.allowReqBody.Load())
+
+	require.NoError(t, m.Load(context.Background()))
+	assert.False(t, b.allowReqBody.Load())
+	assert.True(t, b.allowRespBody.Load())
+}
@@ -0,0 +1,42 @@
+package metric
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ActiveRequests 按照路由统计处理中的请求数
+// 和 _active_req 一起在 metric 中间件里面维护，限流降载之类的中间件可以直接读取
+type ActiveRequests struct {
+	routes sync.Map
+}
+
+func NewActiveRequests() *ActiveRequests {
+	return &ActiveRequests{}
+}
+
+// Inc 返回加一之后的值
+func (a *ActiveRequests) Inc(route string) int64 {
+	return a.counter(route).Add(1)
+}
+
+func (a *ActiveRequests) Dec(route string) {
+	a.counter(route).Add(-1)
+}
+
+// Load 路由当前处理中的请求数
+func (a *ActiveRequests) Load(route string) int64 {
+	cnt, ok := a.routes.Load(route)
+	if !ok {
+		return 0
+	}
+	return cnt.(*atomic.Int64).Load()
+}
+
+func (a *ActiveRequests) counter(route string) *atomic.Int64 {
+	cnt, ok := a.routes.Load(route)
+	if !ok {
+		cnt, _ = a.routes.LoadOrStore(route, &atomic.Int64{})
+	}
+	return cnt.(*atomic.Int64)
+}
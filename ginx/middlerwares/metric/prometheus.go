@@ -13,6 +13,8 @@ type MiddlewareBuilder struct {
 	Name       string
 	Help       string
 	InstanceID string
+	// active 不为 nil 的时候，同时按照路由统计处理中的请求数
+	active *ActiveRequests
 }
 
 func NewBuilder(Namespace string,
@@ -29,6 +31,12 @@ func NewBuilder(Namespace string,
 	}
 }
 
+// Active 按照路由统计处理中的请求数，给 shedding 之类的中间件使用
+func (m *MiddlewareBuilder) Active(active *ActiveRequests) *MiddlewareBuilder {
+	m.active = active
+	return m
+}
+
 func (m *MiddlewareBuilder) Build() gin.HandlerFunc {
 	labels := []string{"method", "pattern", "status"}
 	vector := prometheus.NewSummaryVec(prometheus.SummaryOpts{
@@ -63,14 +71,20 @@ func (m *MiddlewareBuilder) Build() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		method := ctx.Request.Method
 		start := time.Now()
+		// 404????
+		pattern := ctx.FullPath()
+		if pattern == "" {
+			pattern = "unknown"
+		}
 		gauge.Inc()
+		if m.active != nil {
+			m.active.Inc(pattern)
+		}
 		defer func() {
 			duration := time.Since(start)
-			gauge.Desc()
-			// 404????
-			pattern := ctx.FullPath()
-			if pattern == "" {
-				pattern = "unknown"
+			gauge.Dec()
+			if m.active != nil {
+				m.active.Dec(pattern)
 			}
 			vector.WithLabelValues(method, pattern,
 				strconv.Itoa(ctx.Writer.Status())).Observe(float64(duration.Milliseconds()))
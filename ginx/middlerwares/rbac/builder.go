@@ -0,0 +1,68 @@
+package rbac
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Builder 路由级别的权限校验，按照 method + ctx.FullPath() 匹配策略
+// 要放在 jwtx 中间件的后面
+type Builder struct {
+	engine  *rbac.Engine
+	subject func(ctx *gin.Context) (rbac.Subject, bool)
+}
+
+func NewBuilder(engine *rbac.Engine) *Builder {
+	return &Builder{
+		engine:  engine,
+		subject: claimsSubject,
+	}
+}
+
+// Subject 自定义获取 Subject 的方式，返回 false 代表没有登录
+// 默认从 ctx.Get("user") 里面拿 Claims，Claims 需要实现 rbac.SubjectProvider
+func (b *Builder) Subject(fn func(ctx *gin.Context) (rbac.Subject, bool)) *Builder {
+	b.subject = fn
+	return b
+}
+
+// Build 没有登录返回 401，没有权限返回 403
+// Public 的路由不需要登录，例如 jwtx 的 IgnorePaths
+// 没有命中路由的请求（404）不做校验
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			return
+		}
+		sub, ok := b.subject(ctx)
+		if !ok {
+			if b.engine.Public(ctx.Request.Method, route) {
+				return
+			}
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		err := b.engine.Authorize(ctx.Request.Method, route, sub)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+	}
+}
+
+func claimsSubject(ctx *gin.Context) (rbac.Subject, bool) {
+	val, ok := ctx.Get("user")
+	if !ok {
+		return rbac.Subject{}, false
+	}
+	p, ok := val.(rbac.SubjectProvider)
+	if !ok {
+		return rbac.Subject{}, false
+	}
+	return rbac.Subject{
+		Roles:       p.GetRoles(),
+		Permissions: p.GetPermissions(),
+	}, true
+}
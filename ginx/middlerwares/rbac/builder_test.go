@@ -0,0 +1,117 @@
+package rbac
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type userClaims struct {
+	Roles []string
+}
+
+func (u *userClaims) GetRoles() []string {
+	return u.Roles
+}
+
+func (u *userClaims) GetPermissions() []string {
+	return nil
+}
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := rbac.NewEngine([]rbac.Policy{
+		{Method: "POST", Path: "/users/login", Public: true},
+		{Method: "GET", Path: "/articles/:id"},
+		{Method: "*", Path: "/admin/*", Roles: []string{"admin"}},
+	})
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		claims any
+
+		wantCode int
+	}{
+		{
+			name:     "有权限",
+			method:   http.MethodDelete,
+			path:     "/admin/articles/123",
+			claims:   &userClaims{Roles: []string{"admin"}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "按照路由匹配",
+			method:   http.MethodGet,
+			path:     "/articles/123",
+			claims:   &userClaims{},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "没有权限",
+			method:   http.MethodDelete,
+			path:     "/admin/articles/123",
+			claims:   &userClaims{Roles: []string{"author"}},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "没有登录",
+			method:   http.MethodGet,
+			path:     "/articles/123",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "公开的路由不需要登录",
+			method:   http.MethodPost,
+			path:     "/users/login",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "公开的路由登录了也可以访问",
+			method:   http.MethodPost,
+			path:     "/users/login",
+			claims:   &userClaims{},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Claims 没有实现 SubjectProvider",
+			method:   http.MethodGet,
+			path:     "/articles/123",
+			claims:   "abc",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "没有命中路由",
+			method:   http.MethodGet,
+			path:     "/not_found",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set("user", tc.claims)
+				}
+			}, NewBuilder(engine).Build())
+			ok := func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			}
+			server.GET("/articles/:id", ok)
+			server.DELETE("/admin/articles/:id", ok)
+			server.POST("/users/login", ok)
+
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+		})
+	}
+}
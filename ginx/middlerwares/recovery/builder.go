@@ -0,0 +1,138 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// Builder 捕获 panic，替代 gin.Recovery
+// 1. 记录堆栈，通过 accesslog 打印
+// 2. 在当前 span 上记录错误
+// 3. 记录 panic 次数
+// 4. 使用 ginx.Result 响应 500
+// 和 trace 中间件一起使用的时候，必须注册在 trace 之后，也就是
+// server.Use(trace.NewBuilder(...).Build(), recovery.NewBuilder(l).Build())
+// 这样 panic 的时候 ctx 里面才有 span，trace 也能看到 500 的响应
+// 反过来的话，trace 在 panic 的时候直接退出，span 上没有响应码和错误
+type Builder struct {
+	l         accesslog.Logger
+	stackSize int
+	vector    *prometheus.CounterVec
+}
+
+func NewBuilder(l accesslog.Logger) *Builder {
+	return &Builder{
+		l:         l,
+		stackSize: 4096,
+	}
+}
+
+// StackSize 堆栈的最大字节数，默认 4096
+func (b *Builder) StackSize(size int) *Builder {
+	b.stackSize = size
+	return b
+}
+
+// Counter 记录 panic 次数，标签是 method 和 route
+func (b *Builder) Counter(opt prometheus.CounterOpts) *Builder {
+	b.vector = prometheus.NewCounterVec(opt, []string{"method", "route"})
+	prometheus.MustRegister(b.vector)
+	return b
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			b.handle(ctx, rec)
+		}()
+		ctx.Next()
+	}
+}
+
+func (b *Builder) handle(ctx *gin.Context, rec any) {
+	var err error
+	switch recType := rec.(type) {
+	case error:
+		err = recType
+	default:
+		err = fmt.Errorf("%v", rec)
+	}
+	route := ctx.FullPath()
+	l := b.l.WithContext(ctx.Request.Context())
+	fields := []accesslog.Field{
+		accesslog.String("path", ctx.Request.URL.Path),
+		accesslog.String("route", route),
+		accesslog.String("method", ctx.Request.Method),
+		accesslog.Error(err),
+	}
+
+	// 客户端断开连接，没有必要响应，也不需要堆栈
+	if brokenPipe(err) {
+		l.Warn("连接已经断开", fields...)
+		_ = ctx.Error(err)
+		ctx.Abort()
+		return
+	}
+
+	stack := make([]byte, b.stackSize)
+	stack = stack[:runtime.Stack(stack, false)]
+	l.Error("panic", append(fields, accesslog.String("stack", string(stack)))...)
+
+	span := trace.SpanFromContext(ctx.Request.Context())
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "panic")
+
+	if b.vector != nil {
+		if route == "" {
+			route = "unknown"
+		}
+		b.vector.WithLabelValues(ctx.Request.Method, route).Inc()
+	}
+
+	_ = ctx.Error(err)
+	if ctx.Writer.Written() {
+		// 已经开始响应了，没有办法再修改
+		ctx.Abort()
+		return
+	}
+	ctx.AbortWithStatusJSON(http.StatusInternalServerError, ginx.Result{
+		Code: ginx.ErrInternal.Code,
+		Msg:  ginx.ErrInternal.Msg,
+	})
+}
+
+// brokenPipe 和 gin.Recovery 的判断一样
+func brokenPipe(err error) bool {
+	if errors.Is(err, http.ErrAbortHandler) {
+		return true
+	}
+	var ne *net.OpError
+	if !errors.As(err, &ne) {
+		return false
+	}
+	var se *os.SyscallError
+	if errors.As(ne, &se) {
+		msg := strings.ToLower(se.Error())
+		return strings.Contains(msg, "broken pipe") ||
+			strings.Contains(msg, "connection reset by peer") ||
+			errors.Is(se.Err, syscall.EPIPE) ||
+			errors.Is(se.Err, syscall.ECONNRESET)
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package recovery
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/dadaxiaoxiao/go-pkg/ginx/middlerwares/trace"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name    string
+		handler gin.HandlerFunc
+
+		wantCode   int
+		wantResult *ginx.Result
+		wantLevel  accesslog.Level
+		wantMsg    string
+		wantSpan   bool
+	}{
+		{
+			name: "panic 字符串",
+			handler: func(ctx *gin.Context) {
+				panic("mock panic")
+			},
+			wantCode:   http.StatusInternalServerError,
+			wantResult: &ginx.Result{Code: ginx.ErrInternal.Code, Msg: "系统错误"},
+			wantLevel:  accesslog.ErrorLevel,
+			wantMsg:    "panic",
+			wantSpan:   true,
+		},
+		{
+			name: "已经开始响应",
+			handler: func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, "hello")
+				panic(errors.New("mock error"))
+			},
+			wantCode:  http.StatusOK,
+			wantLevel: accesslog.ErrorLevel,
+			wantMsg:   "panic",
+			wantSpan:  true,
+		},
+		{
+			name: "连接断开",
+			handler: func(ctx *gin.Context) {
+				panic(http.ErrAbortHandler)
+			},
+			wantCode:  http.StatusOK,
+			wantLevel: accesslog.WarnLevel,
+			wantMsg:   "连接已经断开",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := logtest.NewRecorder()
+			sr := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				spanCtx, span := tracer.Start(ctx.Request.Context(), ctx.FullPath())
+				defer span.End()
+				ctx.Request = ctx.Request.WithContext(spanCtx)
+				ctx.Next()
+			}, NewBuilder(l).Build())
+			server.GET("/users/:id", tc.handler)
+
+			req, err := http.NewRequest(http.MethodGet, "/users/123", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantResult != nil {
+				var res ginx.Result
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+				assert.Equal(t, *tc.wantResult, res)
+			}
+			entry := l.AssertLogged(t, tc.wantLevel, tc.wantMsg)
+			entry.AssertField(t, "route", "/users/:id")
+			entry.AssertField(t, "method", http.MethodGet)
+			_, hasStack := entry.Field("stack")
+			assert.Equal(t, tc.wantSpan, hasStack)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			if tc.wantSpan {
+				assert.Equal(t, codes.Error, spans[0].Status().Code)
+				assert.Len(t, spans[0].Events(), 1)
+			} else {
+				assert.Equal(t, codes.Unset, spans[0].Status().Code)
+			}
+		})
+	}
+}
+
+// TestBuilder_WithTrace recovery 注册在 trace 之后
+func TestBuilder_WithTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := logtest.NewRecorder()
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	server := gin.New()
+	server.Use(trace.NewBuilder(tracer, nil).Build(), NewBuilder(l).Build())
+	server.GET("/users/:id", func(ctx *gin.Context) {
+		panic("mock panic")
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "/users/123", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	entry := l.AssertLogged(t, accesslog.ErrorLevel, "panic")
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	// 日志和 span 能关联上
+	entry.AssertField(t, "trace_id", span.SpanContext().TraceID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.Int64("http.response.status_code", http.StatusInternalServerError))
+	// recovery 记录了一次，trace 从 ctx.Errors 又记录了一次
+	assert.Len(t, span.Events(), 2)
+}
@@ -0,0 +1,33 @@
+package requestid
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// Builder 从请求头读取 X-Request-ID，没有的话生成一个
+// 写入 ctx.Request.Context() 和响应头，要放在最前面
+type Builder struct {
+	header string
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		header: requestid.HeaderKey,
+	}
+}
+
+// Header 自定义请求头，默认是 X-Request-ID
+func (b *Builder) Header(header string) *Builder {
+	b.header = header
+	return b
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := requestid.Ensure(ctx.GetHeader(b.header))
+		ctx.Request = ctx.Request.WithContext(requestid.NewContext(ctx.Request.Context(), id))
+		ctx.Header(b.header, id)
+		ctx.Set("request_id", id)
+	}
+}
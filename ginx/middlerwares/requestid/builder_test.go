@@ -0,0 +1,61 @@
+package requestid
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name string
+		id   string
+
+		wantSame bool
+	}{
+		{
+			name:     "使用上游的",
+			id:       "req-123",
+			wantSame: true,
+		},
+		{
+			name: "没有的时候生成",
+		},
+		{
+			name: "上游的不合法",
+			id:   "abc def",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.Use(NewBuilder().Build())
+			var got string
+			server.GET("/profile", func(ctx *gin.Context) {
+				got = requestid.FromContext(ctx.Request.Context())
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "/profile", nil)
+			require.NoError(t, err)
+			if tc.id != "" {
+				req.Header.Set(requestid.HeaderKey, tc.id)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, recorder.Header().Get(requestid.HeaderKey))
+			if tc.wantSame {
+				assert.Equal(t, tc.id, got)
+			} else {
+				assert.NotEqual(t, tc.id, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package shedding
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/dadaxiaoxiao/go-pkg/ginx/middlerwares/metric"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ErrOverloaded 路由处理中的请求太多
+var ErrOverloaded = ginx.RegisterError(ginx.ReservedCodeMin+http.StatusServiceUnavailable, "服务繁忙，请稍后再试",
+	http.StatusServiceUnavailable, accesslog.WarnLevel)
+
+// Builder 按照路由限制处理中的请求数，超过之后直接返回 503
+// 处理中的请求数由 metric 中间件统计，所以必须放在 metric 中间件后面
+//
+//	active := metric.NewActiveRequests()
+//	server.Use(metric.NewBuilder(...).Active(active).Build(),
+//		shedding.NewBuilder(active, 100).Build())
+type Builder struct {
+	active *metric.ActiveRequests
+	// 默认的最大并发数，小于等于 0 表示不限制
+	max int64
+	// 路由 -> 最大并发数
+	routes map[string]int64
+}
+
+func NewBuilder(active *metric.ActiveRequests, max int64) *Builder {
+	return &Builder{
+		active: active,
+		max:    max,
+		routes: make(map[string]int64),
+	}
+}
+
+// Route 单独设置某个路由的最大并发数，route 是注册的路由，例如 /articles/:id
+// 小于等于 0 表示这个路由不限制
+func (b *Builder) Route(route string, max int64) *Builder {
+	b.routes[route] = max
+	return b
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			// 和 metric 中间件保持一致
+			route = "unknown"
+		}
+		max, ok := b.routes[route]
+		if !ok {
+			max = b.max
+		}
+		// metric 中间件已经把当前请求算进去了
+		if max <= 0 || b.active.Load(route) <= max {
+			return
+		}
+		ctx.AbortWithStatusJSON(ErrOverloaded.HTTPStatus, ginx.Result{
+			Code: ErrOverloaded.Code,
+			Msg:  ErrOverloaded.Msg,
+		})
+	}
+}
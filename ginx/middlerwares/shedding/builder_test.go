@@ -0,0 +1,77 @@
+package shedding
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/ginx/middlerwares/metric"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	active := metric.NewActiveRequests()
+	server := gin.New()
+	server.Use(metric.NewBuilder("test", "shedding", "http", "test", "1").Active(active).Build(),
+		NewBuilder(active, 2).Route("/unlimited", 0).Build())
+
+	// 请求阻塞在业务里面，直到 release 关闭
+	release := make(chan struct{})
+	entered := make(chan struct{}, 10)
+	block := func(ctx *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		ctx.String(http.StatusOK, "ok")
+	}
+	server.GET("/limited", block)
+	server.GET("/unlimited", block)
+	server.GET("/other", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	send := func(path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- serve(server, path).Code
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		send("/limited")
+	}
+	for i := 0; i < 3; i++ {
+		send("/unlimited")
+	}
+	for i := 0; i < 5; i++ {
+		<-entered
+	}
+	require.Equal(t, int64(2), active.Load("/limited"))
+
+	// 超过了并发数
+	recorder := serve(server, "/limited")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, `{"code":900503,"msg":"服务繁忙，请稍后再试","data":null}`, recorder.Body.String())
+	// 其他路由不受影响
+	assert.Equal(t, http.StatusOK, serve(server, "/other").Code)
+
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, int64(0), active.Load("/limited"))
+	assert.Equal(t, http.StatusOK, serve(server, "/limited").Code)
+}
+
+func serve(server *gin.Engine, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+	return recorder
+}
@@ -0,0 +1,58 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"github.com/dadaxiaoxiao/go-pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// Builder 给 ctx.Request 设置超时时间
+// 业务不会在另外的 goroutine 里面执行，gin.Context 不是并发安全的
+// 所以业务要使用 ctx.Request.Context() 调用下游，超时之后尽快返回
+// 超时之后业务还没有写响应，返回 ErrTimeout，业务已经写了响应就不处理
+type Builder struct {
+	timeout time.Duration
+	// 路由 -> 超时时间
+	routes map[string]time.Duration
+}
+
+// NewBuilder timeout 是默认的超时时间，小于等于 0 表示默认不设置
+func NewBuilder(timeout time.Duration) *Builder {
+	return &Builder{
+		timeout: timeout,
+		routes:  make(map[string]time.Duration),
+	}
+}
+
+// Route 单独设置某个路由的超时时间，route 是注册的路由，例如 /articles/:id
+// 小于等于 0 表示这个路由不设置超时时间
+func (b *Builder) Route(route string, timeout time.Duration) *Builder {
+	b.routes[route] = timeout
+	return b
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		timeout, ok := b.routes[ctx.FullPath()]
+		if !ok {
+			timeout = b.timeout
+		}
+		if timeout <= 0 {
+			return
+		}
+		reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+
+		if ctx.Writer.Written() || !errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		ctx.AbortWithStatusJSON(ginx.ErrTimeout.HTTPStatus, ginx.Result{
+			Code: ginx.ErrTimeout.Code,
+			Msg:  ginx.ErrTimeout.Msg,
+		})
+	}
+}
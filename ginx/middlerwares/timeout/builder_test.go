@@ -0,0 +1,99 @@
+package timeout
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 业务按照 ctx 的超时时间返回，不写响应
+	slow := func(ctx *gin.Context) {
+		select {
+		case <-ctx.Request.Context().Done():
+		case <-time.After(time.Second):
+			ctx.String(http.StatusOK, "ok")
+		}
+	}
+	testCases := []struct {
+		name    string
+		builder func() *Builder
+		handler gin.HandlerFunc
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "超时",
+			builder: func() *Builder {
+				return NewBuilder(10 * time.Millisecond)
+			},
+			handler:  slow,
+			wantCode: http.StatusGatewayTimeout,
+			wantBody: `{"code":900504,"msg":"请求超时","data":null}`,
+		},
+		{
+			name: "没有超时",
+			builder: func() *Builder {
+				return NewBuilder(time.Second)
+			},
+			handler: func(ctx *gin.Context) {
+				_, ok := ctx.Request.Context().Deadline()
+				assert.True(t, ok)
+				ctx.String(http.StatusOK, "ok")
+			},
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+		{
+			name: "超时之后业务已经写了响应",
+			builder: func() *Builder {
+				return NewBuilder(10 * time.Millisecond)
+			},
+			handler: func(ctx *gin.Context) {
+				<-ctx.Request.Context().Done()
+				ctx.String(http.StatusBadGateway, "downstream")
+			},
+			wantCode: http.StatusBadGateway,
+			wantBody: "downstream",
+		},
+		{
+			name: "路由单独设置超时时间",
+			builder: func() *Builder {
+				return NewBuilder(time.Millisecond).Route("/test", 2*time.Second)
+			},
+			handler:  slow,
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+		{
+			name: "路由不设置超时时间",
+			builder: func() *Builder {
+				return NewBuilder(time.Millisecond).Route("/test", 0)
+			},
+			handler: func(ctx *gin.Context) {
+				_, ok := ctx.Request.Context().Deadline()
+				assert.False(t, ok)
+				ctx.String(http.StatusOK, "ok")
+			},
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.Use(tc.builder().Build())
+			server.GET("/test", tc.handler)
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
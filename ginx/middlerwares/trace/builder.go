@@ -0,0 +1,97 @@
+package trace
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+// Builder HTTP 入口的链路追踪
+// span 会放到 ctx.Request.Context() 里面，业务使用 ctx.Request.Context() 调用 gRPC，
+// grpcx 的 trace 拦截器就能够接上这条链路
+// 注意 gin.Context 本身只有在开启 ContextWithFallback 的时候才能取到 span
+// recovery 中间件要注册在 trace 之后，否则 panic 的请求 span 上没有响应码和错误
+type Builder struct {
+	tracer trace.Tracer
+	// 跨进程传播上下文
+	propagator propagation.TextMapPropagator
+}
+
+// NewBuilder tracer 为 nil 的时候使用全局的 TracerProvider
+// propagator 为 nil 的时候同时支持 W3C traceparent 和 B3 请求头
+func NewBuilder(tracer trace.Tracer, propagator propagation.TextMapPropagator) *Builder {
+	return &Builder{
+		tracer:     tracer,
+		propagator: propagator,
+	}
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	propagator := b.propagator
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+			b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+	}
+	tracer := b.tracer
+	if tracer == nil {
+		tracer = otel.Tracer("github.com/dadaxiaoxiao/go-pkg/ginx/middlerwares/trace")
+	}
+	return func(ctx *gin.Context) {
+		req := ctx.Request
+		// 从请求头中解析出上游的 SpanContext
+		reqCtx := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		route := ctx.FullPath()
+		spanName := route
+		if spanName == "" {
+			// 没有命中路由，避免 span 名字基数太大
+			spanName = "HTTP " + req.Method
+		}
+		reqCtx, span := tracer.Start(reqCtx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.URL.Path),
+				semconv.URLScheme(scheme(req)),
+				semconv.ClientAddress(ctx.ClientIP()),
+				semconv.UserAgentOriginal(req.UserAgent()),
+			))
+		defer span.End()
+		ctx.Request = req.WithContext(reqCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// ginx.Wrap 之类的会把业务返回的 error 记录到 ctx.Errors
+		// 只作为事件记录下来，4xx 是客户端的问题，不算服务端的 span 出错
+		for _, err := range ctx.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(ctx.Errors) > 0 {
+			span.SetAttributes(attribute.Int("gin.errors", len(ctx.Errors)))
+		}
+	}
+}
+
+func scheme(req *http.Request) string {
+	if req.TLS != nil {
+		return "https"
+	}
+	if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return strings.ToLower(proto)
+	}
+	return "http"
+}
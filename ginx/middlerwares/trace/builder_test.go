@@ -0,0 +1,139 @@
+package trace
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testCases := []struct {
+		name    string
+		path    string
+		header  map[string]string
+		handler gin.HandlerFunc
+
+		wantName    string
+		wantTraceID string
+		wantParent  bool
+		wantStatus  codes.Code
+		wantEvents  int
+		wantCode    int64
+	}{
+		{
+			name: "W3C",
+			path: "/users/123",
+			header: map[string]string{
+				"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+			},
+			handler: func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			},
+			wantName:    "/users/:id",
+			wantTraceID: traceID,
+			wantParent:  true,
+			wantStatus:  codes.Unset,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name: "B3",
+			path: "/users/123",
+			header: map[string]string{
+				"X-B3-TraceId": traceID,
+				"X-B3-SpanId":  "00f067aa0ba902b7",
+				"X-B3-Sampled": "1",
+			},
+			handler: func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			},
+			wantName:    "/users/:id",
+			wantTraceID: traceID,
+			wantParent:  true,
+			wantStatus:  codes.Unset,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name: "业务错误",
+			path: "/users/123",
+			handler: func(ctx *gin.Context) {
+				_ = ctx.Error(errors.New("mock error"))
+				ctx.Status(http.StatusInternalServerError)
+			},
+			wantName:   "/users/:id",
+			wantStatus: codes.Error,
+			wantEvents: 1,
+			wantCode:   http.StatusInternalServerError,
+		},
+		{
+			name: "4xx 的错误只记录事件",
+			path: "/users/123",
+			handler: func(ctx *gin.Context) {
+				_ = ctx.Error(errors.New("参数错误"))
+				ctx.Status(http.StatusBadRequest)
+			},
+			wantName:   "/users/:id",
+			wantStatus: codes.Unset,
+			wantEvents: 1,
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "没有命中路由",
+			path:       "/not_found",
+			wantName:   "HTTP GET",
+			wantStatus: codes.Unset,
+			wantCode:   http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+			server := gin.New()
+			server.Use(NewBuilder(tracer, nil).Build())
+			var handlerSpan trace.SpanContext
+			if tc.handler != nil {
+				server.GET("/users/:id", func(ctx *gin.Context) {
+					handlerSpan = trace.SpanContextFromContext(ctx.Request.Context())
+					tc.handler(ctx)
+				})
+			}
+
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			for key, val := range tc.header {
+				req.Header.Set(key, val)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tc.wantName, span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Equal(t, tc.wantParent, span.Parent().IsValid())
+			if tc.wantTraceID != "" {
+				assert.Equal(t, tc.wantTraceID, span.SpanContext().TraceID().String())
+			}
+			if tc.handler != nil {
+				// 业务拿到的是这个 span
+				assert.Equal(t, span.SpanContext().SpanID(), handlerSpan.SpanID())
+			}
+			assert.Equal(t, tc.wantStatus, span.Status().Code)
+			assert.Len(t, span.Events(), tc.wantEvents)
+			assert.Contains(t, span.Attributes(), attribute.Int64("http.response.status_code", tc.wantCode))
+		})
+	}
+}
@@ -1,13 +1,80 @@
 package ginx
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"sync"
+)
 
+// Server 内部有锁，创建之后不能复制，请使用 *Server
 type Server struct {
 	*gin.Engine
 	Addr string
+
+	lock   sync.Mutex
+	server *http.Server
+	// Start 之前就调用了 Shutdown
+	closed bool
+	// 已经监听端口，可以接收请求
+	serving bool
 }
 
 // Start 启动gin server
+// 调用 Shutdown 之后返回 nil
 func (s *Server) Start() error {
-	return s.Engine.Run(s.Addr)
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		s.lock.Unlock()
+		return err
+	}
+	s.server = &http.Server{
+		Addr:    s.Addr,
+		Handler: s.Engine,
+	}
+	server := s.server
+	s.serving = true
+	s.lock.Unlock()
+	err = server.Serve(ln)
+	s.lock.Lock()
+	s.serving = false
+	s.lock.Unlock()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// HealthCheck 监听了端口，并且没有开始退出才算健康
+func (s *Server) HealthCheck(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.serving || s.closed {
+		return errors.New("gin server 没有在运行")
+	}
+	return nil
+}
+
+// Shutdown 优雅退出
+// 不再接收新的请求，等待正在处理的请求结束，或者 ctx 超时
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	s.closed = true
+	server := s.server
+	s.lock.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
@@ -1,6 +1,8 @@
 package ginx
 
 import (
+	"context"
+	"errors"
 	"github.com/dadaxiaoxiao/go-pkg/accesslog"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -19,20 +21,12 @@ func InitCounter(opt prometheus.CounterOpts) {
 	prometheus.MustRegister(vector)
 }
 
+// Wrap 返回 gin.HandlerFunc
+// 业务返回的 error 统一处理，参考 render
 func Wrap(fn func(ctx *gin.Context) (Result, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		res, err := fn(ctx)
-		if err != nil {
-			// 记录日志
-			L.Error("处理业务逻辑出错",
-				// http 地址
-				accesslog.String("path", ctx.Request.URL.Path),
-				// 命中路由
-				accesslog.String("route", ctx.FullPath()),
-				accesslog.Error(err))
-		}
-		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
-		ctx.JSON(http.StatusOK, res)
+		render(ctx, L, res, err, ctx.JSON)
 	}
 }
 
@@ -42,21 +36,14 @@ func Wrap(fn func(ctx *gin.Context) (Result, error)) gin.HandlerFunc {
 func WrapBody[T any](l accesslog.Logger, fn func(ctx *gin.Context, req T) (Result, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req T
-		// Bind 方法会根据Content-Type 来解析 到结构体里面
-		if err := ctx.Bind(&req); err != nil {
+		// ShouldBind 方法会根据Content-Type 来解析 到结构体里面
+		if err := ctx.ShouldBind(&req); err != nil {
+			bindFailed(ctx, l, err, ctx.JSONP)
 			return
 		}
 		// 下半段业务逻辑
 		res, err := fn(ctx, req)
-		if err != nil {
-			// 打日志
-			l.Error("处理业务逻辑出错",
-				accesslog.String("path", ctx.Request.URL.Path),
-				accesslog.String("rout", ctx.FullPath()),
-				accesslog.Error(err))
-		}
-		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
-		ctx.JSONP(http.StatusOK, res)
+		render(ctx, l, res, err, ctx.JSONP)
 	}
 }
 
@@ -67,22 +54,14 @@ func WrapBody[T any](l accesslog.Logger, fn func(ctx *gin.Context, req T) (Resul
 func WrapBodyV1[T any](fn func(ctx *gin.Context, req T) (Result, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req T
-		// Bind 方法会根据Content-Type 来解析 到结构体里面
-		if err := ctx.Bind(&req); err != nil {
+		// ShouldBind 方法会根据Content-Type 来解析 到结构体里面
+		if err := ctx.ShouldBind(&req); err != nil {
+			bindFailed(ctx, L, err, ctx.JSONP)
 			return
 		}
 		// 下半段业务逻辑
 		res, err := fn(ctx, req)
-		if err != nil {
-			// 打日志
-			L.Error("处理业务逻辑出错",
-				accesslog.String("path", ctx.Request.URL.Path),
-				accesslog.String("rout", ctx.FullPath()),
-				accesslog.Error(err))
-		}
-		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
-
-		ctx.JSONP(http.StatusOK, res)
+		render(ctx, L, res, err, ctx.JSONP)
 	}
 }
 
@@ -107,15 +86,7 @@ func WrapToken[C jwt.Claims](fn func(ctx *gin.Context, uc C) (Result, error)) gi
 
 		// 下半段业务逻辑
 		res, err := fn(ctx, c)
-		if err != nil {
-			// 打日志
-			L.Error("处理业务逻辑出错",
-				accesslog.String("path", ctx.Request.URL.Path),
-				accesslog.String("rout", ctx.FullPath()),
-				accesslog.Error(err))
-		}
-		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
-		ctx.JSONP(http.StatusOK, res)
+		render(ctx, L, res, err, ctx.JSONP)
 	}
 }
 
@@ -127,8 +98,9 @@ func WrapBodyAndToken[Req any, C jwt.Claims](fn func(ctx *gin.Context, req Req,
 	return func(ctx *gin.Context) {
 
 		var req Req
-		// Bind 方法会根据Content-Type 来解析 到结构体里面
-		if err := ctx.Bind(&req); err != nil {
+		// ShouldBind 方法会根据Content-Type 来解析 到结构体里面
+		if err := ctx.ShouldBind(&req); err != nil {
+			bindFailed(ctx, L, err, ctx.JSONP)
 			return
 		}
 
@@ -147,14 +119,140 @@ func WrapBodyAndToken[Req any, C jwt.Claims](fn func(ctx *gin.Context, req Req,
 		// 下半段业务逻辑
 		// 业务逻辑有可能要操作 ctx
 		res, err := fn(ctx, req, c)
-		if err != nil {
-			// 打日志
-			L.Error("处理业务逻辑出错",
-				accesslog.String("path", ctx.Request.URL.Path),
-				accesslog.String("rout", ctx.FullPath()),
-				accesslog.Error(err))
+		render(ctx, L, res, err, ctx.JSONP)
+	}
+}
+
+// render 统一处理业务返回的结果
+// 1. 没有 error，使用 200 响应 Result
+// 2. error 是 *Error，使用它的 Code, Msg 和 HTTPStatus，按照它的 Level 打印日志
+// 3. error 是 context.DeadlineExceeded，按照 ErrTimeout 处理
+// 4. 其它 error，业务没有填充 Result 的时候按照 ErrInternal 处理，不暴露内部错误，
+// 也就是 (Result{}, err) 以前响应 200，现在响应 500 和 900500；
+// 填充了 Result 的时候保持以前的行为，使用 200 响应
+func render(ctx *gin.Context, l accesslog.Logger, res Result, err error, write func(code int, obj any)) {
+	status := http.StatusOK
+	if err != nil {
+		status, res = handleErr(ctx, l, res, err)
+	}
+	vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
+	write(status, res)
+}
+
+// handleErr 日志里面命中的路由统一使用 route 字段
+// 以前 WrapBody 等方法使用的是 rout，按照日志字段查询的地方要改成 route
+func handleErr(ctx *gin.Context, l accesslog.Logger, res Result, err error) (int, Result) {
+	l = l.WithContext(ctx.Request.Context())
+	fields := []accesslog.Field{
+		// http 地址
+		accesslog.String("path", ctx.Request.URL.Path),
+		// 命中路由
+		accesslog.String("route", ctx.FullPath()),
+		accesslog.Error(err),
+	}
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+	case errors.Is(err, context.DeadlineExceeded):
+		e = ErrTimeout
+	case res.Code == 0 && res.Msg == "" && res.Data == nil:
+		e = ErrInternal
+	default:
+		_ = ctx.Error(err)
+		l.Error("处理业务逻辑出错", fields...)
+		return http.StatusOK, res
+	}
+	if e.HTTPStatus >= http.StatusInternalServerError {
+		// 只把非预期的错误记录到 gin.Context，后面的中间件可以拿到，例如链路追踪
+		// 用户不存在之类的业务错误是正常的结果，不需要记录
+		_ = ctx.Error(err)
+	}
+	fields = append(fields, accesslog.Int64("code", int64(e.Code)))
+	accesslog.Log(l, e.Level, "处理业务逻辑出错", fields...)
+	res.Code = e.Code
+	res.Msg = e.Msg
+	return e.HTTPStatus, res
+}
+
+// WrapQuery 返回 gin.HandlerFunc
+// 用于包装查询参数，使用 form 标签
+// 统一处理日志打印（logger 使用包变量）
+func WrapQuery[T any](fn func(ctx *gin.Context, req T) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req T
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			bindFailed(ctx, L, err, ctx.JSONP)
+			return
+		}
+		res, err := fn(ctx, req)
+		render(ctx, L, res, err, ctx.JSONP)
+	}
+}
+
+// WrapURI 返回 gin.HandlerFunc
+// 用于包装路径参数，使用 uri 标签
+// 统一处理日志打印（logger 使用包变量）
+func WrapURI[T any](fn func(ctx *gin.Context, req T) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req T
+		if err := ctx.ShouldBindUri(&req); err != nil {
+			bindFailed(ctx, L, err, ctx.JSONP)
+			return
+		}
+		res, err := fn(ctx, req)
+		render(ctx, L, res, err, ctx.JSONP)
+	}
+}
+
+// WrapRequest 返回 gin.HandlerFunc
+// 从请求头(header)、查询参数(form)、请求体(json 之类)和路径参数(uri)绑定到同一个结构体
+// 路径参数优先级最高，不会被请求体覆盖
+// 例如：
+//
+//	type GetArticleReq struct {
+//		Id    int64  `uri:"id" binding:"required"`
+//		Biz   string `form:"biz"`
+//		Token string `header:"X-Token"`
+//	}
+//
+// 统一处理日志打印（logger 使用包变量）
+func WrapRequest[T any](fn func(ctx *gin.Context, req T) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req T
+		if err := shouldBindRequest(ctx, &req); err != nil {
+			bindFailed(ctx, L, err, ctx.JSONP)
+			return
 		}
-		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
-		ctx.JSONP(http.StatusOK, res)
+		res, err := fn(ctx, req)
+		render(ctx, L, res, err, ctx.JSONP)
+	}
+}
+
+// WrapRequestAndToken 返回 gin.HandlerFunc
+// 和 WrapRequest 一样绑定请求
+// 统一获取Token 解析的 Claims
+// 统一处理日志打印
+func WrapRequestAndToken[Req any, C jwt.Claims](fn func(ctx *gin.Context, req Req, uc C) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req Req
+		if err := shouldBindRequest(ctx, &req); err != nil {
+			bindFailed(ctx, L, err, ctx.JSONP)
+			return
+		}
+
+		val, ok := ctx.Get("user")
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		c, ok := val.(C)
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		res, err := fn(ctx, req, c)
+		render(ctx, L, res, err, ctx.JSONP)
 	}
 }
@@ -0,0 +1,154 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	// 不注册到全局，避免和业务的指标冲突
+	vector = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ginx_test_code",
+	}, []string{"code"})
+	if err := InitValidator(); err != nil {
+		panic(err)
+	}
+	m.Run()
+}
+
+var errUserNotFound = RegisterError(100001, "用户不存在", http.StatusNotFound, accesslog.WarnLevel)
+
+func TestWrap(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(ctx *gin.Context) (Result, error)
+
+		wantCode   int
+		wantResult Result
+		wantLevel  accesslog.Level
+		wantLogged bool
+		// 是否记录到 ctx.Errors
+		wantCtxErr bool
+	}{
+		{
+			name: "成功",
+			fn: func(ctx *gin.Context) (Result, error) {
+				return Result{Msg: "OK", Data: "hello"}, nil
+			},
+			wantCode:   http.StatusOK,
+			wantResult: Result{Msg: "OK", Data: "hello"},
+		},
+		{
+			name: "业务错误",
+			fn: func(ctx *gin.Context) (Result, error) {
+				return Result{}, errUserNotFound
+			},
+			wantCode:   http.StatusNotFound,
+			wantResult: Result{Code: 100001, Msg: "用户不存在"},
+			wantLevel:  accesslog.WarnLevel,
+			wantLogged: true,
+		},
+		{
+			name: "包装过的业务错误",
+			fn: func(ctx *gin.Context) (Result, error) {
+				return Result{}, fmt.Errorf("查询用户 %w", errUserNotFound.Wrap(errors.New("record not found")))
+			},
+			wantCode:   http.StatusNotFound,
+			wantResult: Result{Code: 100001, Msg: "用户不存在"},
+			wantLevel:  accesslog.WarnLevel,
+			wantLogged: true,
+		},
+		{
+			name: "超时",
+			fn: func(ctx *gin.Context) (Result, error) {
+				return Result{}, fmt.Errorf("查询用户 %w", context.DeadlineExceeded)
+			},
+			wantCode:   http.StatusGatewayTimeout,
+			wantResult: Result{Code: ErrTimeout.Code, Msg: "请求超时"},
+			wantLevel:  accesslog.WarnLevel,
+			wantLogged: true,
+			wantCtxErr: true,
+		},
+		{
+			name: "内部错误，不暴露给用户",
+			fn: func(ctx *gin.Context) (Result, error) {
+				return Result{}, errors.New("数据库连接失败")
+			},
+			wantCode:   http.StatusInternalServerError,
+			wantResult: Result{Code: ErrInternal.Code, Msg: "系统错误"},
+			wantLevel:  accesslog.ErrorLevel,
+			wantLogged: true,
+			wantCtxErr: true,
+		},
+		{
+			name: "业务自己填充了 Result",
+			fn: func(ctx *gin.Context) (Result, error) {
+				return Result{Code: 5, Msg: "系统异常"}, errors.New("数据库连接失败")
+			},
+			wantCode:   http.StatusOK,
+			wantResult: Result{Code: 5, Msg: "系统异常"},
+			wantLevel:  accesslog.ErrorLevel,
+			wantLogged: true,
+			wantCtxErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := logtest.NewRecorder()
+			L = l
+			server := gin.New()
+			var ctxErrs []*gin.Error
+			server.GET("/users/:id", func(ctx *gin.Context) {
+				ctx.Next()
+				ctxErrs = ctx.Errors
+			}, Wrap(tc.fn))
+
+			req, err := http.NewRequest(http.MethodGet, "/users/123", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			var res Result
+			err = json.NewDecoder(recorder.Body).Decode(&res)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantResult, res)
+			if !tc.wantLogged {
+				assert.Equal(t, 0, l.Len())
+				assert.Empty(t, ctxErrs)
+				return
+			}
+			entry := l.AssertLogged(t, tc.wantLevel, "处理业务逻辑出错")
+			entry.AssertField(t, "route", "/users/:id")
+			if tc.wantCtxErr {
+				assert.Len(t, ctxErrs, 1)
+			} else {
+				assert.Empty(t, ctxErrs)
+			}
+		})
+	}
+}
+
+func TestRegisterError(t *testing.T) {
+	assert.PanicsWithValue(t, "ginx: 错误码 100001 重复注册, 已经注册为 用户不存在", func() {
+		RegisterError(100001, "用户不存在", http.StatusNotFound, accesslog.WarnLevel)
+	})
+	e, ok := LookupError(100001)
+	require.True(t, ok)
+	assert.Equal(t, errUserNotFound, e)
+	assert.True(t, errors.Is(errUserNotFound.Wrap(errors.New("mock error")), errUserNotFound))
+	assert.False(t, errors.Is(errUserNotFound, ErrInternal))
+}
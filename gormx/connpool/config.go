@@ -0,0 +1,14 @@
+package connpool
+
+import "github.com/dadaxiaoxiao/go-pkg/configx"
+
+// BindPattern 订阅 configx 的配置，变更的时候切换双写的 pattern
+// 例如 PatternSrcFirst，为空的时候不切换
+func BindPattern[T any](m *configx.Manager[T], selector func(T) string, pool *DoubleWritePool) {
+	configx.Subscribe(m, selector, func(pattern string) {
+		if pattern == "" {
+			return
+		}
+		pool.ChangePattern(pattern)
+	})
+}
@@ -26,12 +26,16 @@ type DoubleWritePool struct {
 	l       accesslog.Logger
 }
 
+// NewDoubleWritePool l 为 nil 的时候不打印日志
 func NewDoubleWritePool(src gorm.ConnPool, dst gorm.ConnPool, pattern string, l accesslog.Logger) *DoubleWritePool {
+	if l == nil {
+		l = accesslog.NewNopLogger()
+	}
 	return &DoubleWritePool{
 		src:     src,
 		dst:     dst,
 		pattern: atomicx.NewValueOf[string](pattern),
-		l:       l,
+		l:       l.Named("double_write_pool"),
 	}
 }
 
@@ -51,18 +55,17 @@ func (d *DoubleWritePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gor
 		return &DoubleWritePoolTx{
 			src:     tx,
 			pattern: PatternSrcOnly,
-			l:       d.l,
+			l:       d.l.With(accesslog.String("pattern", PatternSrcOnly)),
 		}, err
-	case PatternSrcFirst:
-		return d.startTwoTx(d.src, d.src, pattern, ctx, opts)
-	case PatternDstFirst:
-		return d.startTwoTx(d.src, d.src, pattern, ctx, opts)
+	case PatternSrcFirst, PatternDstFirst:
+		// 提交的顺序由 pattern 决定，这里两个事务都要开启
+		return d.startTwoTx(d.src, d.dst, pattern, ctx, opts)
 	case PatternDstOnly:
 		tx, err := d.dst.(gorm.TxBeginner).BeginTx(ctx, opts)
 		return &DoubleWritePoolTx{
 			dst:     tx,
-			pattern: PatternSrcOnly,
-			l:       d.l,
+			pattern: PatternDstOnly,
+			l:       d.l.With(accesslog.String("pattern", PatternDstOnly)),
 		}, err
 	default:
 		return nil, errUnknownPool
@@ -83,11 +86,13 @@ func (d *DoubleWritePool) startTwoTx(
 	}
 	dst, err := second.(gorm.TxBeginner).BeginTx(ctx, opts)
 	if err != nil {
-		d.l.Error("开启 dst 事务失败，回滚 src 事务")
+		d.l.Error("开启 dst 事务失败，回滚 src 事务", accesslog.Error(err))
 		// 容错：回滚 src 事务
 		_ = src.Rollback()
+		return nil, err
 	}
-	return &DoubleWritePoolTx{src: src, dst: dst, pattern: pattern, l: d.l}, nil
+	return &DoubleWritePoolTx{src: src, dst: dst, pattern: pattern,
+		l: d.l.With(accesslog.String("pattern", pattern))}, nil
 }
 
 // PrepareContext 上下文内容
@@ -107,7 +112,7 @@ func (d *DoubleWritePool) ExecContext(ctx context.Context, query string, args ..
 		}
 		_, err1 := d.dst.ExecContext(ctx, query, args...)
 		if err1 != nil {
-			d.l.Error("SRC_FIRST，写入DST 失败", accesslog.Error(err))
+			d.l.Error("SRC_FIRST，写入DST 失败", accesslog.Error(err1))
 		}
 		return res, err
 	case PatternDstFirst:
@@ -117,7 +122,7 @@ func (d *DoubleWritePool) ExecContext(ctx context.Context, query string, args ..
 		}
 		_, err1 := d.src.ExecContext(ctx, query, args...)
 		if err1 != nil {
-			d.l.Error("DST_FIRST，写入SRC 失败", accesslog.Error(err))
+			d.l.Error("DST_FIRST，写入SRC 失败", accesslog.Error(err1))
 		}
 		return res, err
 	case PatternDstOnly:
@@ -251,7 +256,7 @@ func (d *DoubleWritePoolTx) ExecContext(ctx context.Context, query string, args
 		if err1 != nil {
 			// 记日志
 			// 容错：dst 写失败，不被认为是失败；等待后续的校验与修复程序
-			d.l.Error("事务 SRC_FIRST，写入DST 失败", accesslog.Error(err))
+			d.l.Error("事务 SRC_FIRST，写入DST 失败", accesslog.Error(err1))
 		}
 		return res, err
 	case PatternDstFirst:
@@ -263,7 +268,7 @@ func (d *DoubleWritePoolTx) ExecContext(ctx context.Context, query string, args
 		if err1 != nil {
 			// 记日志
 			// dst 写失败，不被认为是失败
-			d.l.Error("事务 DST_FIRST，写入SRC 失败", accesslog.Error(err))
+			d.l.Error("事务 DST_FIRST，写入SRC 失败", accesslog.Error(err1))
 		}
 		return res, err
 	case PatternDstOnly:
@@ -1,7 +1,12 @@
 package connpool
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -65,6 +70,187 @@ func TestDoubleWrite(t *testing.T) {
 	suite.Run(t, &DoubleWriteTestSuite{})
 }
 
+func TestDoubleWritePool_ExecContext(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+		src     *fakeConnPool
+		dst     *fakeConnPool
+		wantErr error
+
+		wantLog string
+	}{
+		{
+			name:    "SRC_FIRST 写入 DST 失败",
+			pattern: PatternSrcFirst,
+			src:     &fakeConnPool{},
+			dst:     &fakeConnPool{err: errors.New("dst error")},
+			wantLog: "SRC_FIRST，写入DST 失败",
+		},
+		{
+			name:    "DST_FIRST 写入 SRC 失败",
+			pattern: PatternDstFirst,
+			src:     &fakeConnPool{err: errors.New("src error")},
+			dst:     &fakeConnPool{},
+			wantLog: "DST_FIRST，写入SRC 失败",
+		},
+		{
+			name:    "SRC_FIRST 写入 SRC 失败",
+			pattern: PatternSrcFirst,
+			src:     &fakeConnPool{err: errors.New("src error")},
+			dst:     &fakeConnPool{},
+			wantErr: errors.New("src error"),
+		},
+		{
+			name:    "未知的模式",
+			pattern: "UNKNOWN",
+			src:     &fakeConnPool{},
+			dst:     &fakeConnPool{},
+			wantErr: errUnknownPool,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := logtest.NewRecorder()
+			pool := NewDoubleWritePool(tc.src, tc.dst, tc.pattern, l)
+			_, err := pool.ExecContext(context.Background(), "UPDATE interactives SET read_cnt = 1")
+			assert.Equal(t, tc.wantErr, err)
+			if tc.wantLog == "" {
+				assert.Equal(t, 0, l.Len())
+				return
+			}
+			entry := l.AssertLogged(t, accesslog.ErrorLevel, tc.wantLog)
+			assert.Equal(t, "double_write_pool", entry.Name)
+			wantErr := tc.src.err
+			if wantErr == nil {
+				wantErr = tc.dst.err
+			}
+			entry.AssertField(t, "error", wantErr)
+		})
+	}
+}
+
+func TestDoubleWritePool_BeginTx(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+
+		wantSrc bool
+		wantDst bool
+	}{
+		{name: "SRC_ONLY", pattern: PatternSrcOnly, wantSrc: true},
+		{name: "SRC_FIRST", pattern: PatternSrcFirst, wantSrc: true, wantDst: true},
+		{name: "DST_FIRST", pattern: PatternDstFirst, wantSrc: true, wantDst: true},
+		{name: "DST_ONLY", pattern: PatternDstOnly, wantDst: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src, dst := &fakeConnPool{}, &fakeConnPool{}
+			// logger 为 nil 的时候不打印日志
+			pool := NewDoubleWritePool(src, dst, tc.pattern, nil)
+			tx, err := pool.BeginTx(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.pattern, tx.(*DoubleWritePoolTx).pattern)
+			assert.Equal(t, tc.wantSrc, src.began)
+			assert.Equal(t, tc.wantDst, dst.began)
+		})
+	}
+}
+
+// TestDoubleWritePool_BeginTxFailed 开启 dst 事务失败的时候，回滚 src 事务并且返回错误
+func TestDoubleWritePool_BeginTxFailed(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+	}{
+		{name: "SRC_FIRST", pattern: PatternSrcFirst},
+		{name: "DST_FIRST", pattern: PatternDstFirst},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			connector := &fakeConnector{}
+			src := &fakeConnPool{db: sql.OpenDB(connector)}
+			dst := &fakeConnPool{beginErr: errors.New("mock error")}
+			l := logtest.NewRecorder()
+			pool := NewDoubleWritePool(src, dst, tc.pattern, l)
+			tx, err := pool.BeginTx(context.Background(), nil)
+			assert.Equal(t, dst.beginErr, err)
+			assert.Nil(t, tx)
+			assert.Equal(t, 1, connector.rollbacks)
+			entry := l.AssertLogged(t, accesslog.ErrorLevel, "开启 dst 事务失败，回滚 src 事务")
+			entry.AssertField(t, "error", dst.beginErr)
+		})
+	}
+}
+
+// fakeConnPool 只实现 ExecContext 和 BeginTx
+// db 为 nil 的时候 BeginTx 返回 nil
+type fakeConnPool struct {
+	gorm.ConnPool
+	err      error
+	beginErr error
+	began    bool
+	db       *sql.DB
+}
+
+func (f *fakeConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	f.began = true
+	if f.beginErr != nil || f.db == nil {
+		return nil, f.beginErr
+	}
+	return f.db.BeginTx(ctx, opts)
+}
+
+// fakeConnector 用来创建真实的 *sql.Tx，记录回滚的次数
+type fakeConnector struct {
+	rollbacks int
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeConn{c: c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return fakeDriver{c: c}
+}
+
+type fakeDriver struct {
+	c *fakeConnector
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{c: d.c}, nil
+}
+
+type fakeConn struct {
+	c *fakeConnector
+}
+
+func (f fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f fakeConn) Close() error {
+	return nil
+}
+
+func (f fakeConn) Begin() (driver.Tx, error) {
+	return f, nil
+}
+
+func (f fakeConn) Commit() error {
+	return nil
+}
+
+func (f fakeConn) Rollback() error {
+	f.c.rollbacks++
+	return nil
+}
+
+func (f *fakeConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, f.err
+}
+
 type Interactive struct {
 	Id    int64 `gorm:"primaryKey,autoIncrement"`
 	BizId int64 `gorm:"uniqueIndex:biz_id_type"`
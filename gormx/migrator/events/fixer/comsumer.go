@@ -19,6 +19,10 @@ type Consumer[T migrator.Entity] struct {
 	srcFirst *fixer.OverrideFixer[T]
 	dstFirst *fixer.OverrideFixer[T]
 	topic    string
+
+	cg      sarama.ConsumerGroup
+	cancel  context.CancelFunc
+	handler *saramax.Handler[events.InconsistentEvent]
 }
 
 func NewConsumer[T migrator.Entity](
@@ -52,16 +56,43 @@ func (c *Consumer[T]) Start() error {
 	if err != nil {
 		return err
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cg = cg
+	c.cancel = cancel
+	c.handler = saramax.NewHandler[events.InconsistentEvent](c.l, c.Consume)
 	//消费
 	go func() {
-		er := cg.Consume(context.Background(),
-			[]string{c.topic},
-			saramax.NewHandler[events.InconsistentEvent](c.l, c.Consume))
-		if er != nil {
-			c.l.Error("退出了消费循环异常", accesslog.Error(err))
+		// rebalance 的时候 Consume 会返回，需要重新调用才能继续消费
+		for {
+			if er := cg.Consume(ctx, []string{c.topic}, c.handler); er != nil {
+				if errors.Is(er, sarama.ErrClosedConsumerGroup) {
+					return
+				}
+				c.l.Error("消费循环异常", accesslog.Error(er))
+			}
+			if ctx.Err() != nil {
+				return
+			}
 		}
 	}()
-	return err
+	return nil
+}
+
+// Close 退出消费循环，离开消费者组
+func (c *Consumer[T]) Close() error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	return c.cg.Close()
+}
+
+// HealthCheck 加入了消费者组才算健康
+func (c *Consumer[T]) HealthCheck(ctx context.Context) error {
+	if c.handler == nil {
+		return errors.New("消费者没有启动")
+	}
+	return c.handler.HealthCheck(ctx)
 }
 
 func (c *Consumer[T]) Consume(msg *sarama.ConsumerMessage, t events.InconsistentEvent) error {
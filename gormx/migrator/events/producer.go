@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/IBM/sarama"
+	"github.com/dadaxiaoxiao/go-pkg/saramax"
 )
 
 //go:generate mockgen.exe -source=./producer.go -package=evtmocks -destination=mocks/producer.mock.go Producer
@@ -29,9 +30,12 @@ func (s *SaramaProducer) ProduceInconsistentEvent(ctx context.Context, evt Incon
 	if err != nil {
 		return err
 	}
-	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+	msg := &sarama.ProducerMessage{
 		Topic: s.topic,
 		Value: sarama.ByteEncoder(data),
-	})
+	}
+	// 传递链路信息给修复程序
+	saramax.InjectContext(ctx, msg)
+	_, _, err = s.producer.SendMessage(msg)
 	return err
 }
@@ -25,8 +25,9 @@ func NewCanalIncrValidator[T migrator.Entity](
 			base:      base,
 			target:    target,
 			direction: direction,
-			l:         l,
-			producer:  producer,
+			// 所有日志都带上校验方向
+			l:        l.With(accesslog.String("direction", direction)),
+			producer: producer,
 		},
 	}
 }
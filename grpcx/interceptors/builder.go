@@ -2,6 +2,7 @@ package interceptors
 
 import (
 	"context"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"net"
@@ -36,6 +37,11 @@ func (b *Builder) PeerIp(ctx context.Context) string {
 	return ""
 }
 
+// RequestID 获取对端传过来的 request id
+func (b *Builder) RequestID(ctx context.Context) string {
+	return b.grpcHeaderValue(ctx, requestid.MetadataKey)
+}
+
 // grpcHeaderValue
 // 获取 heard MetaData
 func (b *Builder) grpcHeaderValue(ctx context.Context, key string) string {
@@ -28,6 +28,7 @@ func (i *InterceptorBuilder) BuildServer() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
 		start := time.Now()
 		var event = "normal"
+		var stack []byte
 		defer func() {
 			duration := time.Since(start)
 			// 为了防止 panicking
@@ -39,8 +40,9 @@ func (i *InterceptorBuilder) BuildServer() grpc.UnaryServerInterceptor {
 				default:
 					err = fmt.Errorf("%v", rec)
 				}
-				stack := make([]byte, 4096)
-				stack = stack[:runtime.Stack(stack, true)]
+				// 只需要当前 goroutine 的调用栈
+				stack = make([]byte, 4096)
+				stack = stack[:runtime.Stack(stack, false)]
 				event = "recover"
 				err = status.New(codes.Internal, "panic, err "+err.Error()).Err()
 			}
@@ -62,8 +64,14 @@ func (i *InterceptorBuilder) BuildServer() grpc.UnaryServerInterceptor {
 					accesslog.String("code", st.Code().String()),
 					accesslog.String("code_msg", st.Message()))
 			}
+			if len(stack) > 0 {
+				// panic 的时候才有调用栈，使用 Error 级别
+				fields = append(fields, accesslog.String("stack", string(stack)))
+				i.l.WithContext(ctx).Error("RPC 请求", fields...)
+				return
+			}
 
-			i.l.Info("RPC 请求", fields...)
+			i.l.WithContext(ctx).Info("RPC 请求", fields...)
 
 		}()
 		resp, err = handler(ctx, req)
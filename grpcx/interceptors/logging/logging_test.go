@@ -3,15 +3,18 @@ package logging
 import (
 	"context"
 	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"github.com/dadaxiaoxiao/go-pkg/accesslog/logtest"
 	"github.com/dadaxiaoxiao/go-pkg/grpcx"
 	"github.com/dadaxiaoxiao/go-pkg/internal/api/user"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	etcdv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/naming/resolver"
-	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"testing"
 	"time"
 )
@@ -29,9 +32,7 @@ func (s *InterceptorTestSuite) SetupSuite() {
 	require.NoError(s.T(), err)
 	s.etcdClient = client
 
-	log, err := zap.NewDevelopment()
-	require.NoError(s.T(), err)
-	s.log = accesslog.NewZapLogger(log)
+	s.log = logtest.NewRecorder()
 }
 
 func (s *InterceptorTestSuite) TestGrpcServer() {
@@ -78,6 +79,68 @@ func TestInterceptor(t *testing.T) {
 	suite.Run(t, new(InterceptorTestSuite))
 }
 
+func TestInterceptorBuilder_BuildServer(t *testing.T) {
+	testCases := []struct {
+		name    string
+		handler grpc.UnaryHandler
+		wantErr error
+
+		wantLevel accesslog.Level
+		wantEvent string
+		wantCode  string
+		wantStack bool
+	}{
+		{
+			name: "正常请求",
+			handler: func(ctx context.Context, req any) (any, error) {
+				return &user.GetByIdResponse{}, nil
+			},
+			wantLevel: accesslog.InfoLevel,
+			wantEvent: "normal",
+		},
+		{
+			name: "业务返回错误",
+			handler: func(ctx context.Context, req any) (any, error) {
+				return nil, status.Error(codes.NotFound, "用户不存在")
+			},
+			wantErr:   status.Error(codes.NotFound, "用户不存在"),
+			wantLevel: accesslog.InfoLevel,
+			wantEvent: "normal",
+			wantCode:  codes.NotFound.String(),
+		},
+		{
+			name: "panic",
+			handler: func(ctx context.Context, req any) (any, error) {
+				panic("mock panic")
+			},
+			wantErr:   status.Error(codes.Internal, "panic, err mock panic"),
+			wantLevel: accesslog.ErrorLevel,
+			wantEvent: "recover",
+			wantCode:  codes.Internal.String(),
+			wantStack: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := logtest.NewRecorder()
+			interceptor := NewInterceptorBuilder(l).BuildServer()
+			_, err := interceptor(context.Background(), &user.GetByIdRequest{Id: 1},
+				&grpc.UnaryServerInfo{FullMethod: "/user.v1.UserService/GetById"}, tc.handler)
+			assert.Equal(t, tc.wantErr, err)
+
+			entry := l.AssertLogged(t, tc.wantLevel, "RPC 请求")
+			entry.AssertField(t, "method", "/user.v1.UserService/GetById")
+			entry.AssertField(t, "event", tc.wantEvent)
+			if tc.wantCode != "" {
+				entry.AssertField(t, "code", tc.wantCode)
+			}
+			_, ok := entry.Field("stack")
+			assert.Equal(t, tc.wantStack, ok)
+		})
+	}
+}
+
 type Server struct {
 	user.UnimplementedUserServiceServer
 }
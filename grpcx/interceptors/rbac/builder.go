@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/rbac"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SubjectFunc 从 ctx 里面获取 Subject，返回 false 代表没有登录
+// 一般是前面的拦截器解析了 metadata 里面的 token
+type SubjectFunc func(ctx context.Context) (rbac.Subject, bool)
+
+// InterceptorBuilder 使用和 gin 一样的策略引擎
+// 策略的 Method 是 rbac.MethodGRPC，Path 是 FullMethod
+type InterceptorBuilder struct {
+	engine  *rbac.Engine
+	subject SubjectFunc
+}
+
+func NewInterceptorBuilder(engine *rbac.Engine, subject SubjectFunc) *InterceptorBuilder {
+	return &InterceptorBuilder{
+		engine:  engine,
+		subject: subject,
+	}
+}
+
+func (i *InterceptorBuilder) BuildServer() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if err = i.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *InterceptorBuilder) BuildStreamServer() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (i *InterceptorBuilder) authorize(ctx context.Context, fullMethod string) error {
+	sub, ok := i.subject(ctx)
+	if !ok {
+		if i.engine.Public(rbac.MethodGRPC, fullMethod) {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "没有登录")
+	}
+	err := i.engine.Authorize(rbac.MethodGRPC, fullMethod, sub)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, "没有权限")
+	}
+	return nil
+}
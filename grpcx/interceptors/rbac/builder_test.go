@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/rbac"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+type subjectKey struct{}
+
+func TestInterceptorBuilder_BuildServer(t *testing.T) {
+	engine := rbac.NewEngine([]rbac.Policy{
+		{Method: rbac.MethodGRPC, Path: "/grpc.health.v1.Health/*", Public: true},
+		{Method: rbac.MethodGRPC, Path: "/user.v1.UserService/*", Roles: []string{"service"}},
+	})
+	interceptor := NewInterceptorBuilder(engine, func(ctx context.Context) (rbac.Subject, bool) {
+		sub, ok := ctx.Value(subjectKey{}).(rbac.Subject)
+		return sub, ok
+	}).BuildServer()
+	testCases := []struct {
+		name   string
+		ctx    context.Context
+		method string
+
+		wantCode codes.Code
+	}{
+		{
+			name:     "有权限",
+			ctx:      context.WithValue(context.Background(), subjectKey{}, rbac.Subject{Roles: []string{"service"}}),
+			method:   "/user.v1.UserService/Profile",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "没有权限",
+			ctx:      context.WithValue(context.Background(), subjectKey{}, rbac.Subject{}),
+			method:   "/user.v1.UserService/Profile",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "没有匹配的策略",
+			ctx:      context.WithValue(context.Background(), subjectKey{}, rbac.Subject{Roles: []string{"service"}}),
+			method:   "/article.v1.ArticleService/Detail",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "没有登录",
+			ctx:      context.Background(),
+			method:   "/user.v1.UserService/Profile",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "公开的方法不需要登录",
+			ctx:      context.Background(),
+			method:   "/grpc.health.v1.Health/Check",
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := interceptor(tc.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method},
+				func(ctx context.Context, req any) (any, error) {
+					return nil, nil
+				})
+			assert.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package requestid
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/grpcx/interceptors"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// InterceptorBuilder 通过 metadata 的 x-request-id 传递 request id
+// 服务端放在最前面，后面的日志拦截器就能打印出来
+type InterceptorBuilder struct {
+	interceptors.Builder
+}
+
+func NewInterceptorBuilder() *InterceptorBuilder {
+	return &InterceptorBuilder{}
+}
+
+// BuildServer 从 metadata 读取，没有的话生成一个，写入 ctx
+func (i *InterceptorBuilder) BuildServer() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		return handler(i.serverContext(ctx), req)
+	}
+}
+
+func (i *InterceptorBuilder) BuildStreamServer() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          i.serverContext(ss.Context()),
+		})
+	}
+}
+
+// BuildClient 把 ctx 里面的 request id 写入 metadata
+func (i *InterceptorBuilder) BuildClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(clientContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+func (i *InterceptorBuilder) BuildStreamClient() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(clientContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+func (i *InterceptorBuilder) serverContext(ctx context.Context) context.Context {
+	id := requestid.Ensure(i.RequestID(ctx))
+	return requestid.NewContext(ctx, id)
+}
+
+func clientContext(ctx context.Context) context.Context {
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok && len(md.Get(requestid.MetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, id)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
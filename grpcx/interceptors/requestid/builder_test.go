@@ -0,0 +1,59 @@
+package requestid
+
+import (
+	"context"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func TestInterceptorBuilder(t *testing.T) {
+	i := NewInterceptorBuilder()
+
+	// 客户端把 ctx 里面的 request id 写入 metadata
+	var outgoing metadata.MD
+	err := i.BuildClient()(requestid.NewContext(context.Background(), "req-123"),
+		"/user.v1.UserService/Profile", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			outgoing, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-123"}, outgoing.Get(requestid.MetadataKey))
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+
+		wantID string
+	}{
+		{
+			name:   "从 metadata 读取",
+			ctx:    metadata.NewIncomingContext(context.Background(), outgoing),
+			wantID: "req-123",
+		},
+		{
+			name: "没有的时候生成",
+			ctx:  context.Background(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			_, err := i.BuildServer()(tc.ctx, nil, &grpc.UnaryServerInfo{},
+				func(ctx context.Context, req any) (any, error) {
+					got = requestid.FromContext(ctx)
+					return nil, nil
+				})
+			require.NoError(t, err)
+			if tc.wantID != "" {
+				assert.Equal(t, tc.wantID, got)
+			} else {
+				assert.Len(t, got, 32)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package grpcx
 
 import (
 	"context"
+	"errors"
 	"github.com/dadaxiaoxiao/go-pkg/accesslog"
 	"github.com/dadaxiaoxiao/go-pkg/netx"
 	etcdv3 "go.etcd.io/etcd/client/v3"
@@ -9,6 +10,8 @@ import (
 	"google.golang.org/grpc"
 	"net"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,11 +22,19 @@ type Server struct {
 	Log  accesslog.Logger
 
 	// ETCD 服务注册租约 TTL
-	EtcdTTL     int64
-	EtcdClient  *etcdv3.Client
+	EtcdTTL    int64
+	EtcdClient *etcdv3.Client
+
+	// Serve 和 Close 通常在不同的 goroutine 里面调用
+	// lock 保护下面这些在 Serve 里面设置，在 Close 里面读取的字段
+	lock        sync.Mutex
 	etcdManager endpoints.Manager
 	etcdKey     string
 	cancel      func()
+	// Serve 之前就调用了 Close
+	closed bool
+	// 是否已经注册到 etcd，并且租约还在续
+	registered atomic.Bool
 }
 
 func NewServer(server *grpc.Server, port int,
@@ -39,10 +50,18 @@ func NewServer(server *grpc.Server, port int,
 	}
 }
 
+// Serve 启动 gRPC server 并注册到 etcd
+// 调用 Close 之后返回 nil
 func (s *Server) Serve() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
 	// 这里统一链路控制
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
+	s.lock.Unlock()
 	port := strconv.Itoa(s.Port)
 	// 监听 tcp ，端口
 	l, err := net.Listen("tcp", ":"+port)
@@ -70,10 +89,13 @@ func (s *Server) register(ctx context.Context, port string) error {
 	if err != nil {
 		return err
 	}
-	s.etcdManager = em
 	ip := netx.GetOutboundIP()
 	add := ip + ":" + port
-	s.etcdKey = "service/" + s.Name + "/" + add
+	key := "service/" + s.Name + "/" + add
+	s.lock.Lock()
+	s.etcdManager = em
+	s.etcdKey = key
+	s.lock.Unlock()
 
 	// 开启续约,心跳机制
 	leaseResp, err := cli.Grant(ctx, s.EtcdTTL)
@@ -93,26 +115,46 @@ func (s *Server) register(ctx context.Context, port string) error {
 			// 这里每次续约都会打一次
 			s.Log.Debug("续约心跳", accesslog.String("message", chResp.String()))
 		}
+		// channel 关闭说明续约停止了，租约到期之后就会被注册中心摘掉
+		s.registered.Store(false)
 	}()
 
 	// 注册入注册中心
-	return em.AddEndpoint(ctx, s.etcdKey, endpoints.Endpoint{
+	err = em.AddEndpoint(ctx, key, endpoints.Endpoint{
 		Addr: add,
 	}, etcdv3.WithLease(leaseResp.ID))
+	if err != nil {
+		return err
+	}
+	s.registered.Store(true)
+	return nil
+}
+
+// HealthCheck 已经注册到 etcd 并且还在续约才算健康
+func (s *Server) HealthCheck(ctx context.Context) error {
+	if !s.registered.Load() {
+		return errors.New("gRPC server 没有注册到 etcd")
+	}
+	return nil
 }
 
 // Close 优雅退出
 func (s *Server) Close() error {
-	if s.cancel != nil {
+	s.registered.Store(false)
+	s.lock.Lock()
+	s.closed = true
+	stop, em, key := s.cancel, s.etcdManager, s.etcdKey
+	s.lock.Unlock()
+	if stop != nil {
 		// 停跳心跳机制
-		s.cancel()
+		stop()
 	}
 
-	if s.etcdManager != nil {
+	if em != nil {
 		// 通知注册中心下线,取消注册
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
-		err := s.etcdManager.DeleteEndpoint(ctx, s.etcdKey)
+		err := em.DeleteEndpoint(ctx, key)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,30 @@
+package healthx
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Redis 检查 redis 连接
+func Redis(cmd redis.Cmdable) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return cmd.Ping(ctx).Err()
+	})
+}
+
+// Gorm 检查数据库连接
+func Gorm(db *gorm.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// Component 适配实现了 HealthChecker 的组件
+func Component(c HealthChecker) Checker {
+	return CheckerFunc(c.HealthCheck)
+}
@@ -0,0 +1,105 @@
+package healthx
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"time"
+)
+
+// RegisterRoutes 注册 /healthz 和 /readyz
+// 健康返回 200，不健康返回 503
+func (r *Registry) RegisterRoutes(server gin.IRoutes) {
+	server.GET("/healthz", r.handle(r.Liveness))
+	server.GET("/readyz", r.handle(r.Readiness))
+}
+
+func (r *Registry) handle(fn func(ctx context.Context) Report) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		report := fn(ctx.Request.Context())
+		code := http.StatusOK
+		if !report.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		ctx.JSON(code, report)
+	}
+}
+
+// RegisterGRPC 注册标准的 grpc.health.v1.Health 服务
+// 要在 server 启动之前调用
+func (r *Registry) RegisterGRPC(server *grpc.Server) {
+	grpc_health_v1.RegisterHealthServer(server, NewGRPCHealthServer(r))
+}
+
+// GRPCHealthServer 实现 grpc.health.v1.Health
+// service 为空代表整体的就绪状态，否则是指定名称的就绪检查
+type GRPCHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	r *Registry
+	// Watch 的检查间隔
+	interval time.Duration
+}
+
+func NewGRPCHealthServer(r *Registry) *GRPCHealthServer {
+	return &GRPCHealthServer{
+		r:        r,
+		interval: 5 * time.Second,
+	}
+}
+
+func (s *GRPCHealthServer) Check(ctx context.Context,
+	req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	st, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch 状态变化的时候推送，检查间隔为 5 秒
+func (s *GRPCHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest,
+	stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	for {
+		st, ok := s.status(ctx, req.GetService())
+		if !ok {
+			st = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st})
+			if err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GRPCHealthServer) status(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	if service == "" {
+		if s.r.Readiness(ctx).Healthy() {
+			return grpc_health_v1.HealthCheckResponse_SERVING, true
+		}
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+	}
+	err, ok := s.r.CheckReadiness(ctx, service)
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	if err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, true
+}
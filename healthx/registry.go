@@ -0,0 +1,137 @@
+package healthx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// Report 检查结果
+type Report struct {
+	Status string `json:"status"`
+	// 每一项检查的结果，健康是 ok，不健康是错误信息
+	Checks map[string]string `json:"checks"`
+}
+
+func (r Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Registry 健康检查注册中心
+// liveness 代表进程是否存活，失败了 k8s 会重启容器
+// readiness 代表是否可以接收流量，失败了 k8s 会把实例摘掉
+type Registry struct {
+	lock      sync.RWMutex
+	liveness  []namedChecker
+	readiness []namedChecker
+	// 单项检查的超时时间
+	timeout time.Duration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		timeout: time.Second,
+	}
+}
+
+// Timeout 单项检查的超时时间，默认 1 秒
+func (r *Registry) Timeout(timeout time.Duration) *Registry {
+	r.timeout = timeout
+	return r
+}
+
+// AddLiveness 添加存活检查
+func (r *Registry) AddLiveness(name string, checker Checker) *Registry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.liveness = append(r.liveness, namedChecker{name: name, checker: checker})
+	return r
+}
+
+// AddReadiness 添加就绪检查
+func (r *Registry) AddReadiness(name string, checker Checker) *Registry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.readiness = append(r.readiness, namedChecker{name: name, checker: checker})
+	return r
+}
+
+// Liveness 执行所有存活检查
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.lock.RLock()
+	checkers := r.liveness
+	r.lock.RUnlock()
+	return r.check(ctx, checkers)
+}
+
+// Readiness 执行所有就绪检查
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.lock.RLock()
+	checkers := r.readiness
+	r.lock.RUnlock()
+	return r.check(ctx, checkers)
+}
+
+// CheckReadiness 执行指定名称的就绪检查
+// 和 Readiness 一样，执行检查的时候不持有锁，避免慢的检查阻塞 AddReadiness
+func (r *Registry) CheckReadiness(ctx context.Context, name string) (error, bool) {
+	r.lock.RLock()
+	checkers := r.readiness
+	r.lock.RUnlock()
+	for _, c := range checkers {
+		if c.name == name {
+			return r.checkOne(ctx, c.checker), true
+		}
+	}
+	return nil, false
+}
+
+// check 并发执行检查
+func (r *Registry) check(ctx context.Context, checkers []namedChecker) Report {
+	res := Report{
+		Status: StatusUp,
+		Checks: make(map[string]string, len(checkers)),
+	}
+	errs := make([]error, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c Checker) {
+			defer wg.Done()
+			errs[i] = r.checkOne(ctx, c)
+		}(i, c.checker)
+	}
+	wg.Wait()
+	for i, c := range checkers {
+		if errs[i] != nil {
+			res.Status = StatusDown
+			res.Checks[c.name] = errs[i].Error()
+			continue
+		}
+		res.Checks[c.name] = "ok"
+	}
+	return res
+}
+
+func (r *Registry) checkOne(ctx context.Context, c Checker) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	defer func() {
+		// 检查本身 panic 不能影响进程
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("检查 panic %v", rec)
+		}
+	}()
+	return c.Check(ctx)
+}
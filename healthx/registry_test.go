@@ -0,0 +1,204 @@
+package healthx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Readiness(t *testing.T) {
+	testCases := []struct {
+		name     string
+		checkers map[string]Checker
+
+		wantReport Report
+	}{
+		{
+			name: "全部健康",
+			checkers: map[string]Checker{
+				"redis": CheckerFunc(func(ctx context.Context) error { return nil }),
+				"mysql": CheckerFunc(func(ctx context.Context) error { return nil }),
+			},
+			wantReport: Report{
+				Status: StatusUp,
+				Checks: map[string]string{"redis": "ok", "mysql": "ok"},
+			},
+		},
+		{
+			name: "一项不健康",
+			checkers: map[string]Checker{
+				"redis": CheckerFunc(func(ctx context.Context) error { return nil }),
+				"mysql": CheckerFunc(func(ctx context.Context) error { return errors.New("mock error") }),
+			},
+			wantReport: Report{
+				Status: StatusDown,
+				Checks: map[string]string{"redis": "ok", "mysql": "mock error"},
+			},
+		},
+		{
+			name: "检查超时",
+			checkers: map[string]Checker{
+				"slow": CheckerFunc(func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}),
+			},
+			wantReport: Report{
+				Status: StatusDown,
+				Checks: map[string]string{"slow": context.DeadlineExceeded.Error()},
+			},
+		},
+		{
+			name: "检查 panic",
+			checkers: map[string]Checker{
+				"panic": CheckerFunc(func(ctx context.Context) error { panic("mock panic") }),
+			},
+			wantReport: Report{
+				Status: StatusDown,
+				Checks: map[string]string{"panic": "检查 panic mock panic"},
+			},
+		},
+		{
+			name: "没有检查",
+			wantReport: Report{
+				Status: StatusUp,
+				Checks: map[string]string{},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRegistry().Timeout(10 * time.Millisecond)
+			for name, c := range tc.checkers {
+				r.AddReadiness(name, c)
+			}
+			assert.Equal(t, tc.wantReport, r.Readiness(context.Background()))
+			// 就绪检查不影响存活检查
+			assert.True(t, r.Liveness(context.Background()).Healthy())
+		})
+	}
+}
+
+func TestRegistry_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRegistry().
+		AddLiveness("process", CheckerFunc(func(ctx context.Context) error { return nil })).
+		AddReadiness("mysql", CheckerFunc(func(ctx context.Context) error { return errors.New("mock error") }))
+	server := gin.New()
+	r.RegisterRoutes(server)
+
+	testCases := []struct {
+		name string
+		path string
+
+		wantCode   int
+		wantReport Report
+	}{
+		{
+			name:     "存活",
+			path:     "/healthz",
+			wantCode: http.StatusOK,
+			wantReport: Report{
+				Status: StatusUp,
+				Checks: map[string]string{"process": "ok"},
+			},
+		},
+		{
+			name:     "没有就绪",
+			path:     "/readyz",
+			wantCode: http.StatusServiceUnavailable,
+			wantReport: Report{
+				Status: StatusDown,
+				Checks: map[string]string{"mysql": "mock error"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			var report Report
+			err = json.NewDecoder(recorder.Body).Decode(&report)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantReport, report)
+		})
+	}
+}
+
+func TestGRPCHealthServer_Check(t *testing.T) {
+	r := NewRegistry().
+		AddReadiness("redis", CheckerFunc(func(ctx context.Context) error { return nil })).
+		AddReadiness("mysql", CheckerFunc(func(ctx context.Context) error { return errors.New("mock error") }))
+	server := NewGRPCHealthServer(r)
+
+	testCases := []struct {
+		name    string
+		service string
+
+		wantStatus grpc_health_v1.HealthCheckResponse_ServingStatus
+		wantCode   codes.Code
+	}{
+		{
+			name:       "整体状态",
+			wantStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:       "单项健康",
+			service:    "redis",
+			wantStatus: grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		{
+			name:       "单项不健康",
+			service:    "mysql",
+			wantStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:     "未知服务",
+			service:  "kafka",
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := server.Check(context.Background(),
+				&grpc_health_v1.HealthCheckRequest{Service: tc.service})
+			assert.Equal(t, tc.wantCode, status.Code(err))
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantStatus, resp.GetStatus())
+		})
+	}
+}
+
+// TestRegistry_CheckReadiness 执行检查的时候不持有锁
+func TestRegistry_CheckReadiness(t *testing.T) {
+	r := NewRegistry().Timeout(100 * time.Millisecond)
+	r.AddReadiness("grpc", CheckerFunc(func(ctx context.Context) error {
+		r.AddReadiness("gin", CheckerFunc(func(ctx context.Context) error { return nil }))
+		return nil
+	}))
+	err, ok := r.CheckReadiness(context.Background(), "grpc")
+	require.True(t, ok)
+	assert.NoError(t, err)
+	_, ok = r.CheckReadiness(context.Background(), "gin")
+	assert.True(t, ok)
+	_, ok = r.CheckReadiness(context.Background(), "unknown")
+	assert.False(t, ok)
+}
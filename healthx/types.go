@@ -0,0 +1,21 @@
+package healthx
+
+import "context"
+
+// Checker 健康检查，返回 nil 代表健康
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 利用适配器 适配 Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (c CheckerFunc) Check(ctx context.Context) error {
+	return c(ctx)
+}
+
+// HealthChecker 组件实现这个接口，就可以把自己的状态贡献给 Registry
+// 例如 grpcx.Server, ginx.Server 和 saramax.Handler
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
@@ -22,14 +22,16 @@ func (c *CronJobBuilder) Build(job Job) cron.Job {
 	name := job.Name()
 	return cronJobFuncAdapter(func() error {
 		// tracer 追踪
-		_, span := c.tracer.Start(context.Background(), name)
+		ctx, span := c.tracer.Start(context.Background(), name)
 		defer span.End()
+		// 日志关联 tracer
+		l := c.l.WithContext(ctx)
 		start := time.Now()
-		c.l.Info("任务开始",
+		l.Info("任务开始",
 			accesslog.String("job", name))
 		var success bool
 		defer func() {
-			c.l.Info("任务结束",
+			l.Info("任务结束",
 				accesslog.String("job", name))
 
 			duration := time.Since(start).Milliseconds()
@@ -43,7 +45,7 @@ func (c *CronJobBuilder) Build(job Job) cron.Job {
 			// tracer 记录错误
 			span.RecordError(err)
 			// 日志打印
-			c.l.Error("执行任务失败",
+			l.Error("执行任务失败",
 				accesslog.Error(err), accesslog.String("job", name))
 		}
 		return nil
@@ -0,0 +1,18 @@
+package ratelimit
+
+import (
+	"github.com/dadaxiaoxiao/go-pkg/configx"
+	"io"
+)
+
+// BindLimiter 订阅 configx 的配置，变更的时候用 build 创建新的限流器替换旧的
+// 旧的限流器如果实现了 io.Closer 会被关闭
+func BindLimiter[T any, C any](m *configx.Manager[T], selector func(T) C,
+	limiter *DynamicLimiter, build func(C) Limiter) {
+	configx.Subscribe(m, selector, func(cfg C) {
+		old := limiter.Update(build(cfg))
+		if closer, ok := old.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	})
+}
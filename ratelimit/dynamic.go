@@ -0,0 +1,28 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// DynamicLimiter 可以在运行时替换的限流器
+// 例如配置变更之后调整阈值
+type DynamicLimiter struct {
+	limiter atomic.Pointer[Limiter]
+}
+
+func NewDynamicLimiter(l Limiter) *DynamicLimiter {
+	res := &DynamicLimiter{}
+	res.limiter.Store(&l)
+	return res
+}
+
+func (d *DynamicLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	return (*d.limiter.Load()).Limit(ctx, key)
+}
+
+// Update 替换限流器，返回旧的限流器
+// 旧的限流器由调用者负责关闭
+func (d *DynamicLimiter) Update(l Limiter) Limiter {
+	return *d.limiter.Swap(&l)
+}
@@ -23,6 +23,7 @@ func NewLeakyBucketLimiter(interval time.Duration) *LeakyBucket {
 	return &LeakyBucket{
 		interval: interval,
 		ticker:   ticker,
+		closeCh:  make(chan struct{}),
 	}
 }
 
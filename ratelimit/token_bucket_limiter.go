@@ -24,6 +24,7 @@ func NewTokenBucketLimiter(interval time.Duration, capacity int) *TokenBucketLim
 	res := &TokenBucketLimiter{
 		interval: interval,
 		buckets:  make(chan struct{}, capacity),
+		closeCh:  make(chan struct{}),
 	}
 	defer func() {
 		go func() {
@@ -0,0 +1,146 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// Engine 策略引擎
+// 按照加入的顺序匹配，第一条匹配上的策略生效，所以具体的策略要放在前面
+// 可以通过 Load 在运行期间整体替换策略，并发安全
+type Engine struct {
+	policies     atomic.Pointer[[]policy]
+	defaultAllow atomic.Bool
+}
+
+func NewEngine(policies []Policy) *Engine {
+	res := &Engine{}
+	res.Load(policies)
+	return res
+}
+
+// DefaultAllow 没有匹配的策略的时候放行，默认拒绝
+func (e *Engine) DefaultAllow(allow bool) *Engine {
+	e.defaultAllow.Store(allow)
+	return e
+}
+
+// Load 整体替换策略，可以配合 configx.Subscribe 使用
+func (e *Engine) Load(policies []Policy) {
+	ps := make([]policy, 0, len(policies))
+	for _, p := range policies {
+		ps = append(ps, newPolicy(p))
+	}
+	e.policies.Store(&ps)
+}
+
+// Reload 通过 Loader 加载策略，失败的时候保留旧的策略
+func (e *Engine) Reload(ctx context.Context, loader Loader) error {
+	policies, err := loader.Load(ctx)
+	if err != nil {
+		return err
+	}
+	e.Load(policies)
+	return nil
+}
+
+// Authorize 判断 sub 能不能访问 method + path
+// 返回 nil 代表可以访问
+func (e *Engine) Authorize(method, path string, sub Subject) error {
+	for _, p := range *e.policies.Load() {
+		if !p.match(method, path) {
+			continue
+		}
+		if p.allow(sub) {
+			return nil
+		}
+		return ErrForbidden
+	}
+	if e.defaultAllow.Load() {
+		return nil
+	}
+	return ErrNoPolicy
+}
+
+// Public 判断 method + path 是不是不需要登录
+// 第一条匹配上的策略是 Public，或者没有匹配的策略并且开启了 DefaultAllow
+func (e *Engine) Public(method, path string) bool {
+	for _, p := range *e.policies.Load() {
+		if p.match(method, path) {
+			return p.public
+		}
+	}
+	return e.defaultAllow.Load()
+}
+
+type policy struct {
+	public bool
+	method string
+	path   string
+	// path 以 /* 结尾的时候，这里是去掉 * 之后的前缀
+	prefix      string
+	roles       []string
+	permissions []string
+}
+
+func newPolicy(p Policy) policy {
+	res := policy{
+		public:      p.Public,
+		method:      strings.ToUpper(p.Method),
+		path:        p.Path,
+		roles:       p.Roles,
+		permissions: p.Permissions,
+	}
+	if strings.HasSuffix(p.Path, "/*") {
+		res.prefix = strings.TrimSuffix(p.Path, "*")
+	}
+	return res
+}
+
+func (p policy) match(method, path string) bool {
+	if p.method != "*" && p.method != method {
+		return false
+	}
+	switch {
+	case p.path == "*":
+		return true
+	case p.prefix != "":
+		return strings.HasPrefix(path, p.prefix)
+	default:
+		return p.path == path
+	}
+}
+
+func (p policy) allow(sub Subject) bool {
+	if p.public {
+		return true
+	}
+	if len(p.roles) > 0 && !containsAny(sub.Roles, p.roles) {
+		return false
+	}
+	for _, perm := range p.permissions {
+		if !contains(sub.Permissions, perm) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(src []string, targets []string) bool {
+	for _, t := range targets {
+		if contains(src, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(src []string, target string) bool {
+	for _, s := range src {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEngine_Authorize(t *testing.T) {
+	policies := []Policy{
+		{Method: "GET", Path: "/articles/:id"},
+		{Method: "POST", Path: "/articles/publish", Roles: []string{"author", "admin"}},
+		{Method: "*", Path: "/admin/users/delete", Permissions: []string{"user:read", "user:delete"}},
+		{Method: "*", Path: "/admin/*", Roles: []string{"admin"}},
+		{Method: MethodGRPC, Path: "/user.v1.UserService/*", Roles: []string{"service"}},
+	}
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		sub          Subject
+		defaultAllow bool
+
+		wantErr error
+	}{
+		{
+			name:   "只需要登录",
+			method: "GET",
+			path:   "/articles/:id",
+		},
+		{
+			name:   "满足任意一个角色",
+			method: "POST",
+			path:   "/articles/publish",
+			sub:    Subject{Roles: []string{"reader", "author"}},
+		},
+		{
+			name:    "角色不满足",
+			method:  "POST",
+			path:    "/articles/publish",
+			sub:     Subject{Roles: []string{"reader"}},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:   "拥有全部权限",
+			method: "DELETE",
+			path:   "/admin/users/delete",
+			sub:    Subject{Permissions: []string{"user:read", "user:delete"}},
+		},
+		{
+			name:    "缺少权限，具体的策略优先，不会匹配到 /admin/*",
+			method:  "DELETE",
+			path:    "/admin/users/delete",
+			sub:     Subject{Roles: []string{"admin"}, Permissions: []string{"user:read"}},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:   "前缀匹配",
+			method: "PUT",
+			path:   "/admin/articles/:id",
+			sub:    Subject{Roles: []string{"admin"}},
+		},
+		{
+			name:    "方法不匹配",
+			method:  "DELETE",
+			path:    "/articles/:id",
+			wantErr: ErrNoPolicy,
+		},
+		{
+			name:         "没有匹配的策略，默认放行",
+			method:       "DELETE",
+			path:         "/articles/:id",
+			defaultAllow: true,
+		},
+		{
+			name:   "gRPC",
+			method: MethodGRPC,
+			path:   "/user.v1.UserService/Profile",
+			sub:    Subject{Roles: []string{"service"}},
+		},
+		{
+			name:    "gRPC 没有权限",
+			method:  MethodGRPC,
+			path:    "/user.v1.UserService/Profile",
+			wantErr: ErrForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEngine(policies).DefaultAllow(tc.defaultAllow)
+			err := e.Authorize(tc.method, tc.path, tc.sub)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestEngine_Public(t *testing.T) {
+	policies := []Policy{
+		{Method: "POST", Path: "/users/login", Public: true},
+		{Method: "GET", Path: "/articles/:id"},
+	}
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		defaultAllow bool
+
+		want bool
+	}{
+		{
+			name:   "公开的策略",
+			method: "POST",
+			path:   "/users/login",
+			want:   true,
+		},
+		{
+			name:   "需要登录",
+			method: "GET",
+			path:   "/articles/:id",
+		},
+		{
+			name:   "没有匹配的策略",
+			method: "GET",
+			path:   "/healthz",
+		},
+		{
+			name:         "没有匹配的策略，默认放行",
+			method:       "GET",
+			path:         "/healthz",
+			defaultAllow: true,
+			want:         true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEngine(policies).DefaultAllow(tc.defaultAllow)
+			assert.Equal(t, tc.want, e.Public(tc.method, tc.path))
+		})
+	}
+}
+
+func TestEngine_Reload(t *testing.T) {
+	e := NewEngine(nil)
+	assert.Equal(t, ErrNoPolicy, e.Authorize("GET", "/profile", Subject{}))
+
+	err := e.Reload(context.Background(), loaderFunc(func(ctx context.Context) ([]Policy, error) {
+		return []Policy{{Method: "GET", Path: "/profile"}}, nil
+	}))
+	assert.NoError(t, err)
+	assert.NoError(t, e.Authorize("GET", "/profile", Subject{}))
+
+	// 加载失败，保留旧的策略
+	err = e.Reload(context.Background(), loaderFunc(func(ctx context.Context) ([]Policy, error) {
+		return nil, errors.New("mock error")
+	}))
+	assert.Error(t, err)
+	assert.NoError(t, e.Authorize("GET", "/profile", Subject{}))
+}
+
+type loaderFunc func(ctx context.Context) ([]Policy, error)
+
+func (l loaderFunc) Load(ctx context.Context) ([]Policy, error) {
+	return l(ctx)
+}
@@ -0,0 +1,68 @@
+package rbac
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// PolicyModel 策略表，Roles 和 Permissions 使用逗号分隔
+type PolicyModel struct {
+	Id          int64  `gorm:"primaryKey;autoIncrement"`
+	Method      string `gorm:"type:varchar(16)"`
+	Path        string `gorm:"type:varchar(256)"`
+	Roles       string `gorm:"type:varchar(1024)"`
+	Permissions string `gorm:"type:varchar(1024)"`
+	Public      bool
+	// Priority 越小越先匹配
+	Priority int
+	Ctime    int64
+	Utime    int64
+}
+
+func (PolicyModel) TableName() string {
+	return "rbac_policies"
+}
+
+// GormLoader 从数据库加载策略
+type GormLoader struct {
+	db *gorm.DB
+}
+
+func NewGormLoader(db *gorm.DB) *GormLoader {
+	return &GormLoader{
+		db: db,
+	}
+}
+
+func (g *GormLoader) Load(ctx context.Context) ([]Policy, error) {
+	var models []PolicyModel
+	err := g.db.WithContext(ctx).
+		Order("priority ASC, id ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Policy, 0, len(models))
+	for _, m := range models {
+		res = append(res, Policy{
+			Method:      m.Method,
+			Path:        m.Path,
+			Roles:       split(m.Roles),
+			Permissions: split(m.Permissions),
+			Public:      m.Public,
+		})
+	}
+	return res, nil
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	res := strings.Split(s, ",")
+	for i := range res {
+		res[i] = strings.TrimSpace(res[i])
+	}
+	return res
+}
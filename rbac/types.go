@@ -0,0 +1,49 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrForbidden 没有权限
+	ErrForbidden = errors.New("rbac: 没有权限")
+	// ErrNoPolicy 没有匹配的策略，并且没有开启 DefaultAllow
+	ErrNoPolicy = errors.New("rbac: 没有匹配的策略")
+)
+
+// MethodGRPC gRPC 的策略使用这个 Method，Path 是 FullMethod
+// 例如 /user.v1.UserService/Profile
+const MethodGRPC = "GRPC"
+
+// Policy 一条策略
+// Roles 满足任意一个即可，Permissions 需要全部拥有
+// 两者都为空代表只需要登录，Public 代表不需要登录
+type Policy struct {
+	// Method HTTP 方法，* 代表所有方法
+	Method string `yaml:"method" json:"method"`
+	// Path 对应 gin 的 ctx.FullPath() 或者 gRPC 的 FullMethod
+	// 支持精确匹配，/admin/* 这种前缀匹配，以及 * 匹配所有
+	Path        string   `yaml:"path" json:"path"`
+	Roles       []string `yaml:"roles" json:"roles"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+	// Public 不需要登录就能访问，例如登录、刷新 token 和健康检查
+	Public bool `yaml:"public" json:"public"`
+}
+
+// Subject 访问者
+type Subject struct {
+	Roles       []string
+	Permissions []string
+}
+
+// SubjectProvider 业务的 Claims 实现这个接口，中间件就可以直接拿到 Subject
+type SubjectProvider interface {
+	GetRoles() []string
+	GetPermissions() []string
+}
+
+// Loader 从外部加载策略，例如数据库
+type Loader interface {
+	Load(ctx context.Context) ([]Policy, error)
+}
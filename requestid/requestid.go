@@ -0,0 +1,61 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// HeaderKey HTTP 请求头和响应头
+	HeaderKey = "X-Request-ID"
+	// MetadataKey gRPC metadata 和 kafka 消息头，metadata 的 key 只能是小写
+	MetadataKey = "x-request-id"
+	// maxLength 上游传过来的 request id 太长的时候重新生成，避免被用来攻击日志
+	maxLength = 128
+)
+
+type ctxKey struct{}
+
+// NewContext 把 request id 放到 ctx 里面
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 没有的时候返回空字符串
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New 生成一个新的 request id，32 位十六进制字符串
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid 上游传过来的 request id 是否可以直接使用
+// 只允许可打印的 ASCII 字符，并且长度不超过 128
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Ensure 上游的 request id 合法就使用，否则生成一个新的
+func Ensure(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return New()
+}
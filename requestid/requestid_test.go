@@ -0,0 +1,57 @@
+package requestid
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestEnsure(t *testing.T) {
+	testCases := []struct {
+		name string
+		id   string
+
+		wantSame bool
+	}{
+		{
+			name:     "合法",
+			id:       "0af7651916cd43dd8448eb211c80319c",
+			wantSame: true,
+		},
+		{
+			name: "空",
+		},
+		{
+			name: "太长",
+			id:   strings.Repeat("a", 129),
+		},
+		{
+			name: "包含换行",
+			id:   "abc\nfake log",
+		},
+		{
+			name: "包含空格",
+			id:   "abc def",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id := Ensure(tc.id)
+			if tc.wantSame {
+				assert.Equal(t, tc.id, id)
+				return
+			}
+			assert.Len(t, id, 32)
+			assert.NotEqual(t, tc.id, id)
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, "", FromContext(context.Background()))
+	ctx := NewContext(context.Background(), "abc")
+	assert.Equal(t, "abc", FromContext(ctx))
+	assert.NotEqual(t, New(), New())
+}
@@ -3,8 +3,10 @@ package saramax
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/IBM/sarama"
 	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +19,8 @@ type BatchHandler[T any] struct {
 	fn        func(msg []*sarama.ConsumerMessage, t []T) error
 	batchSize int
 	duration  time.Duration
+	// 是否加入了消费者组，并且分配了分区
+	attached atomic.Bool
 }
 
 func NewBatchHandler[T any](l accesslog.Logger,
@@ -44,16 +48,31 @@ func WithDuration[T any](duration time.Duration) BatchHandlerOption[T] {
 }
 
 func (b *BatchHandler[T]) Setup(session sarama.ConsumerGroupSession) error {
+	b.attached.Store(true)
 	return nil
 }
 
 func (b *BatchHandler[T]) Cleanup(session sarama.ConsumerGroupSession) error {
+	b.attached.Store(false)
+	return nil
+}
+
+// HealthCheck 加入了消费者组才算健康
+// rebalance 的时候会短暂不健康
+func (b *BatchHandler[T]) HealthCheck(ctx context.Context) error {
+	if !b.attached.Load() {
+		return errors.New("没有加入消费者组")
+	}
 	return nil
 }
 
 func (b *BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	msgCh := claim.Messages()
 	batchSize := b.batchSize
+	// 同一个 claim 的 topic 和 partition 是固定的
+	l := b.l.With(
+		accesslog.String("topic", claim.Topic()),
+		accesslog.Int32("partition", claim.Partition()))
 	for {
 		ctx, cancel := context.WithTimeout(context.Background(), b.duration)
 		done := false
@@ -77,10 +96,8 @@ func (b *BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 				err := json.Unmarshal(msg.Value, &t)
 				if err != nil {
 					// 日志打印
-					b.l.Error("反序列消息失败",
+					l.WithContext(ExtractContext(msg)).Error("反序列消息失败",
 						accesslog.Error(err),
-						accesslog.String("topic", msg.Topic),
-						accesslog.Int32("partition", msg.Partition),
 						accesslog.Int64("offset", msg.Offset))
 					continue
 				}
@@ -95,7 +112,7 @@ func (b *BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 
 		err := b.fn(msgs, ts)
 		if err != nil {
-			b.l.Error("调用业务批量接口失败",
+			l.Error("调用业务批量接口失败",
 				accesslog.Error(err))
 		}
 
@@ -0,0 +1,110 @@
+package saramax
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"go.opentelemetry.io/otel"
+)
+
+// ProducerMessageCarrier 生产者消息头搬运工
+// 发送端将 SpanContext 注入到消息头中
+type ProducerMessageCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func NewProducerMessageCarrier(msg *sarama.ProducerMessage) ProducerMessageCarrier {
+	return ProducerMessageCarrier{msg: msg}
+}
+
+func (c ProducerMessageCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c ProducerMessageCarrier) Set(key string, value string) {
+	// 覆盖同名的消息头
+	for i := range c.msg.Headers {
+		if string(c.msg.Headers[i].Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{
+		Key:   []byte(key),
+		Value: []byte(value),
+	})
+}
+
+func (c ProducerMessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// ConsumerMessageCarrier 消费者消息头搬运工
+// 接收端从消息头中解析出 SpanContext
+type ConsumerMessageCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+func NewConsumerMessageCarrier(msg *sarama.ConsumerMessage) ConsumerMessageCarrier {
+	return ConsumerMessageCarrier{msg: msg}
+}
+
+func (c ConsumerMessageCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c ConsumerMessageCarrier) Set(key string, value string) {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			h.Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, &sarama.RecordHeader{
+		Key:   []byte(key),
+		Value: []byte(value),
+	})
+}
+
+func (c ConsumerMessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
+
+// InjectContext 将 ctx 中的链路信息和 request id 写入消息头
+func InjectContext(ctx context.Context, msg *sarama.ProducerMessage) {
+	carrier := NewProducerMessageCarrier(msg)
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if id := requestid.FromContext(ctx); id != "" {
+		carrier.Set(requestid.MetadataKey, id)
+	}
+}
+
+// ExtractContext 从消息头中解析出链路信息和 request id
+func ExtractContext(msg *sarama.ConsumerMessage) context.Context {
+	carrier := NewConsumerMessageCarrier(msg)
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	if id := carrier.Get(requestid.MetadataKey); requestid.Valid(id) {
+		ctx = requestid.NewContext(ctx, id)
+	}
+	return ctx
+}
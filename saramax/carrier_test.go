@@ -0,0 +1,43 @@
+package saramax
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/dadaxiaoxiao/go-pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInjectContext_RequestID(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  context.Context
+
+		wantID string
+	}{
+		{
+			name:   "传递 request id",
+			ctx:    requestid.NewContext(context.Background(), "req-123"),
+			wantID: "req-123",
+		},
+		{
+			name: "没有 request id",
+			ctx:  context.Background(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pmsg := &sarama.ProducerMessage{Topic: "test"}
+			InjectContext(tc.ctx, pmsg)
+			// 模拟消费者收到的消息
+			cmsg := &sarama.ConsumerMessage{Topic: "test"}
+			for _, h := range pmsg.Headers {
+				h := h
+				cmsg.Headers = append(cmsg.Headers, &h)
+			}
+			ctx := ExtractContext(cmsg)
+			assert.Equal(t, tc.wantID, requestid.FromContext(ctx))
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package saramax
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/IBM/sarama"
 	"github.com/dadaxiaoxiao/go-pkg/accesslog"
+	"sync/atomic"
 )
 
 // Handler 单个消费
@@ -11,6 +14,8 @@ import (
 type Handler[T any] struct {
 	l  accesslog.Logger
 	fn func(msg *sarama.ConsumerMessage, t T) error
+	// 是否加入了消费者组，并且分配了分区
+	attached atomic.Bool
 }
 
 func NewHandler[T any](l accesslog.Logger,
@@ -23,28 +28,42 @@ func NewHandler[T any](l accesslog.Logger,
 
 func (h *Handler[T]) Setup(session sarama.ConsumerGroupSession) error {
 	// 初始化
+	h.attached.Store(true)
 	return nil
 }
 
 func (h *Handler[T]) Cleanup(session sarama.ConsumerGroupSession) error {
 	// 清理
+	h.attached.Store(false)
+	return nil
+}
+
+// HealthCheck 加入了消费者组才算健康
+// rebalance 的时候会短暂不健康
+func (h *Handler[T]) HealthCheck(ctx context.Context) error {
+	if !h.attached.Load() {
+		return errors.New("没有加入消费者组")
+	}
 	return nil
 }
 
 // ConsumeClaim 单个消费，单个提交
 func (h *Handler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	msgs := claim.Messages()
+	// 同一个 claim 的 topic 和 partition 是固定的
+	cl := h.l.With(
+		accesslog.String("topic", claim.Topic()),
+		accesslog.Int32("partition", claim.Partition()))
 	// 循环获取channel 消息
 	for msg := range msgs {
+		// 通过消息头关联上游链路
+		l := cl.WithContext(ExtractContext(msg)).
+			With(accesslog.Int64("offset", msg.Offset))
 		var t T
 		err := json.Unmarshal(msg.Value, &t)
 		if err != nil {
 			// 日志打印
-			h.l.Error("反序列消息失败",
-				accesslog.Error(err),
-				accesslog.String("topic", msg.Topic),
-				accesslog.Int32("partition", msg.Partition),
-				accesslog.Int64("offset", msg.Offset))
+			l.Error("反序列消息失败", accesslog.Error(err))
 			continue
 		}
 
@@ -54,19 +73,11 @@ func (h *Handler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sar
 			if err == nil {
 				break
 			}
-			h.l.Error("处理消息失败",
-				accesslog.Error(err),
-				accesslog.String("topic", msg.Topic),
-				accesslog.Int32("partition", msg.Partition),
-				accesslog.Int64("offset", msg.Offset))
+			l.Error("处理消息失败", accesslog.Error(err))
 		}
 
 		if err != nil {
-			h.l.Error("处理消息失败-重试次数上限",
-				accesslog.Error(err),
-				accesslog.String("topic", msg.Topic),
-				accesslog.Int32("partition", msg.Partition),
-				accesslog.Int64("offset", msg.Offset))
+			l.Error("处理消息失败-重试次数上限", accesslog.Error(err))
 		} else {
 			// 标记消费
 			session.MarkMessage(msg, "")
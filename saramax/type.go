@@ -3,3 +3,9 @@ package saramax
 type Consumer interface {
 	Start() error
 }
+
+// Closer 支持优雅退出的消费者
+// customserver.App 退出的时候会调用 Close
+type Closer interface {
+	Close() error
+}